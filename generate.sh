@@ -1,3 +1,21 @@
 #!/bin/bash
 
-protoc --go_out=./server --go-grpc_out=./server proto/*.proto
+PROTO_INCLUDES="-I . -I proto/third_party"
+
+protoc $PROTO_INCLUDES \
+  --go_out=./server --go-grpc_out=./server \
+  --grpc-gateway_out=./server \
+  proto/*.proto
+
+# The Swagger docs served under /swagger are generated from the same HTTP annotations.
+protoc $PROTO_INCLUDES \
+  --openapiv2_out=./www/docs \
+  --openapiv2_opt=allow_merge=true,merge_file_name=swagger,json_names_for_fields=false \
+  proto/*.proto
+mv ./www/docs/swagger.swagger.json ./www/docs/swagger.json
+
+protoc $PROTO_INCLUDES \
+  --openapiv2_out=./www/docs \
+  --openapiv2_opt=allow_merge=true,merge_file_name=swagger,json_names_for_fields=false,output_format=yaml \
+  proto/*.proto
+mv ./www/docs/swagger.swagger.yaml ./www/docs/swagger.yaml
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	manager "go-auth/server/jwt"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"go-auth/server/repository"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 500
+
+	// Status filter of ListUsers selecting soft deleted accounts.
+	userStatusDeleted = "deleted"
+)
+
+var errAccountDisabled = status.Error(codes.PermissionDenied, "account is disabled")
+
+func (s *Server) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, invalidArgument(fieldViolation("page_size", "page_size must not be negative"))
+	case pageSize == 0:
+		pageSize = defaultUserPageSize
+	case pageSize > maxUserPageSize:
+		pageSize = maxUserPageSize
+	}
+
+	// One extra row tells whether there is a next page.
+	filter := repository.UserFilter{NamePrefix: req.GetNamePrefix(), Limit: pageSize + 1}
+
+	switch req.GetStatus() {
+	case "":
+	case models.UserStatusActive, models.UserStatusDisabled, models.UserStatusPendingVerification:
+		filter.Status = req.GetStatus()
+	case userStatusDeleted:
+		filter.Deleted = true
+	default:
+		return nil, invalidArgument(fieldViolation("status", "status must be active, disabled, pending_verification or deleted"))
+	}
+
+	if req.GetCreatedAfter() != nil {
+		createdAfter := req.GetCreatedAfter().AsTime()
+		filter.CreatedAfter = &createdAfter
+	}
+
+	if req.GetCreatedBefore() != nil {
+		createdBefore := req.GetCreatedBefore().AsTime()
+		filter.CreatedBefore = &createdBefore
+	}
+
+	if req.GetPageToken() != "" {
+		afterID, err := decodeUserPageToken(req.GetPageToken())
+		if err != nil {
+			return nil, invalidArgument(fieldViolation("page_token", "invalid page token"))
+		}
+		filter.AfterID = afterID
+	}
+
+	users, err := s.Users.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListUsersResponse{}
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		resp.NextPageToken = encodeUserPageToken(users[len(users)-1].ID)
+	}
+
+	for i := range users {
+		resp.Users = append(resp.Users, toPbUserDetails(&users[i]))
+	}
+
+	return resp, nil
+}
+
+// UpdateUser changes the fields of a user listed in the update mask. Changing
+// roles also requires the permission of AssignRole, rbac:manage.
+func (s *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserDetails, error) {
+	if req.GetUser() == nil {
+		return nil, invalidArgument(fieldViolation("user", "user is required"))
+	}
+
+	if len(req.GetUpdateMask().GetPaths()) == 0 {
+		return nil, invalidArgument(fieldViolation("update_mask", "update_mask is required"))
+	}
+
+	user, err := s.getUserDetails(req.GetUser().GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	var roles []models.Role
+	replaceRoles := false
+	resetMfa := false
+
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "id":
+			// Identifies the user, it cannot be changed.
+		case "name":
+			name := req.GetUser().GetName()
+			if err := requireFields(field{"user.name", name}); err != nil {
+				return nil, err
+			}
+			if err := validateName(name, "user.name"); err != nil {
+				return nil, err
+			}
+
+			taken, err := s.Users.NameTaken(name, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				return nil, status.Error(codes.AlreadyExists, "username already taken")
+			}
+
+			updates["name"] = name
+		case "roles":
+			if !hasPermission(ctx, models.PermissionManageRoles) {
+				return nil, status.Error(codes.PermissionDenied, "changing roles requires the "+models.PermissionManageRoles+" permission")
+			}
+
+			roles, err = s.findRoles(req.GetUser().GetRoles())
+			if err != nil {
+				return nil, err
+			}
+			replaceRoles = true
+		case "mfa_enabled":
+			if req.GetUser().GetMfaEnabled() {
+				return nil, invalidArgument(fieldViolation("user.mfa_enabled", "mfa can only be enabled by the user through enrollment"))
+			}
+
+			updates["mfa_enabled"] = false
+			updates["mfa_secret"] = ""
+			updates["mfa_last_step"] = 0
+			resetMfa = true
+		default:
+			ok, err := s.profileUpdate(user, path, req.GetUser(), "user.", updates)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, invalidArgument(fieldViolation("update_mask", "unsupported path "+path))
+			}
+		}
+	}
+
+	err = s.Transactor.Transaction(func(tx *gorm.DB) error {
+		users := s.Users.WithTx(tx)
+
+		if len(updates) > 0 {
+			if err := users.Update(user.ID, updates); err != nil {
+				return err
+			}
+		}
+
+		if replaceRoles {
+			if err := users.ReplaceRoles(user, roles); err != nil {
+				return err
+			}
+		}
+
+		if resetMfa {
+			return users.ReplaceRecoveryCodes(user.ID, nil)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Updated user %d: %v", user.ID, req.GetUpdateMask().GetPaths())
+
+	return s.reloadUserDetails(user.ID)
+}
+
+// DisableUser blocks logins of the user and ends their sessions, the tokens
+// issued so far are rejected.
+func (s *Server) DisableUser(ctx context.Context, req *pb.DisableUserRequest) (*pb.UserDetails, error) {
+	user, err := s.findUser(s.Users.FindByID, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if isCurrentUser(ctx, user) {
+		return nil, status.Error(codes.FailedPrecondition, "cannot disable your own account")
+	}
+
+	if err := s.setUserStatus(user, models.UserStatusDisabled); err != nil {
+		return nil, err
+	}
+
+	if err := s.Manager.RevokeUserSessions(user.PublicId); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Disabled user: ", user.ID)
+
+	return s.reloadUserDetails(user.ID)
+}
+
+// EnableUser lifts the block of DisableUser. A user whose email address was
+// never verified goes back to pending verification, users that are not
+// disabled are left as they are.
+func (s *Server) EnableUser(ctx context.Context, req *pb.EnableUserRequest) (*pb.UserDetails, error) {
+	user, err := s.findUser(s.Users.FindByID, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == models.UserStatusDisabled {
+		userStatus := models.UserStatusActive
+		if user.Email != nil && user.EmailVerifiedAt == nil {
+			userStatus = models.UserStatusPendingVerification
+		}
+
+		if err := s.setUserStatus(user, userStatus); err != nil {
+			return nil, err
+		}
+
+		s.Logger.Info("Enabled user: ", user.ID)
+	}
+
+	return s.reloadUserDetails(user.ID)
+}
+
+// DeleteUser soft deletes a user, or with hard set removes the account and
+// everything stored for it. Soft deleted accounts can be hard deleted later.
+func (s *Server) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DefaultResponse, error) {
+	find := s.Users.FindByID
+	if req.GetHard() {
+		find = s.Users.FindAnyByID
+	}
+
+	user, err := s.findUser(find, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if isCurrentUser(ctx, user) {
+		return nil, status.Error(codes.FailedPrecondition, "cannot delete your own account")
+	}
+
+	if !req.GetHard() {
+		if err := s.Users.SoftDelete(user.ID); err != nil {
+			return nil, err
+		}
+		if err := s.Manager.RevokeUserSessions(user.PublicId); err != nil {
+			return nil, err
+		}
+
+		s.Logger.Info("Soft deleted user: ", user.ID)
+	} else {
+		// Access tokens carry the authorities of the account, revoke them
+		// while the row still exists.
+		if err := s.Manager.RevokeUserSessions(user.PublicId); err != nil {
+			return nil, err
+		}
+
+		err := s.Transactor.Transaction(func(tx *gorm.DB) error {
+			if err := s.ActionTokens.WithTx(tx).DeleteAll(user.ID); err != nil {
+				return err
+			}
+			return s.Users.WithTx(tx).HardDelete(user)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		s.Logger.Info("Hard deleted user: ", user.ID)
+	}
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+// findUser loads the user with the internal id used by the admin RPCs using
+// one of the lookups of s.Users.
+func (s *Server) findUser(find func(id uint) (*models.User, error), id uint64) (*models.User, error) {
+	user, err := find(uint(id))
+	if err != nil {
+		return nil, notFound(err, "user")
+	}
+
+	return user, nil
+}
+
+func (s *Server) setUserStatus(user *models.User, userStatus string) error {
+	return s.Users.Update(user.ID, map[string]interface{}{"status": userStatus})
+}
+
+func (s *Server) getUserDetails(id uint64) (*models.User, error) {
+	return s.findUser(s.Users.FindWithRoles, id)
+}
+
+func (s *Server) reloadUserDetails(id uint) (*pb.UserDetails, error) {
+	user, err := s.getUserDetails(uint64(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbUserDetails(user), nil
+}
+
+// findRoles returns the roles with the given names, listing a name twice is
+// the same as listing it once.
+func (s *Server) findRoles(names []string) ([]models.Role, error) {
+	unique := map[string]bool{}
+	for _, name := range names {
+		unique[name] = true
+	}
+	if len(unique) == 0 {
+		return nil, nil
+	}
+
+	roles, err := s.Roles.FindByNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) != len(unique) {
+		return nil, invalidArgument(fieldViolation("user.roles", "unknown role"))
+	}
+
+	return roles, nil
+}
+
+// hasPermission reports whether the verified access token grants permission.
+func hasPermission(ctx context.Context, permission string) bool {
+	claims, ok := manager.ClaimsFromContext(ctx)
+	return ok && claims.HasPermissions(permission)
+}
+
+// isCurrentUser reports whether user is the user of the verified access token.
+func isCurrentUser(ctx context.Context, user *models.User) bool {
+	claims, ok := manager.ClaimsFromContext(ctx)
+	return ok && claims.UserId == user.PublicId
+}
+
+func toPbUserDetails(user *models.User) *pb.UserDetails {
+	resp := &pb.UserDetails{
+		Id:         uint64(user.ID),
+		PublicId:   user.PublicId,
+		Name:       user.Name,
+		Status:     user.Status,
+		MfaEnabled: user.MfaEnabled,
+		CreatedAt:  timestamppb.New(user.CreatedAt),
+		UpdatedAt:  timestamppb.New(user.UpdatedAt),
+
+		Email:         userEmail(user),
+		EmailVerified: user.EmailVerifiedAt != nil,
+		DisplayName:   user.DisplayName,
+		AvatarUrl:     user.AvatarUrl,
+		Locale:        user.Locale,
+		Timezone:      user.Timezone,
+		Phone:         user.Phone,
+	}
+	if user.LockedUntil != nil {
+		resp.LockedUntil = timestamppb.New(*user.LockedUntil)
+	}
+	if user.DeletedAt.Valid {
+		resp.DeletedAt = timestamppb.New(user.DeletedAt.Time)
+	}
+	for _, role := range user.Roles {
+		resp.Roles = append(resp.Roles, role.Name)
+	}
+
+	return resp
+}
+
+// The page token is the opaque id of the last user of the previous page.
+func encodeUserPageToken(lastID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(lastID), 10)))
+}
+
+func decodeUserPageToken(token string) (uint, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.ParseUint(string(b), 10, strconv.IntSize)
+	return uint(id), err
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	manager "go-auth/server/jwt"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Roles are changed with the permission of AssignRole, users:manage alone
+// must not let a caller make anyone an admin.
+func TestUpdateUserRoles(t *testing.T) {
+	s := newTestServer(t)
+	_, err := s.CreateRole(context.Background(), &pb.CreateRoleRequest{Name: "support", Permissions: []string{models.PermissionManageUsers}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"admin", "support", "target"} {
+		s.register(t, name)
+	}
+	s.assignRole(t, "admin", models.AdminRole)
+	s.assignRole(t, "support", "support")
+	adminCtx, _ := s.login(t, "admin", testPassword)
+	supportCtx, _ := s.login(t, "support", testPassword)
+	target := uint64(s.userID(t, "target"))
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		user      *pb.UserDetails
+		paths     []string
+		want      codes.Code
+		wantRoles []string
+	}{
+		{"roles without rbac:manage", supportCtx, &pb.UserDetails{Id: target, Roles: []string{models.AdminRole}}, []string{"roles"}, codes.PermissionDenied, []string{models.UserRole}},
+		{"other fields without rbac:manage", supportCtx, &pb.UserDetails{Id: target, Name: "renamed"}, []string{"name"}, codes.OK, []string{models.UserRole}},
+		{"duplicated role", adminCtx, &pb.UserDetails{Id: target, Roles: []string{"support", "support"}}, []string{"roles"}, codes.OK, []string{"support"}},
+		{"unknown role", adminCtx, &pb.UserDetails{Id: target, Roles: []string{"support", "missing"}}, []string{"roles"}, codes.InvalidArgument, []string{"support"}},
+		{"no roles", adminCtx, &pb.UserDetails{Id: target}, []string{"roles"}, codes.OK, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.UpdateUser(tc.ctx, &pb.UpdateUserRequest{User: tc.user, UpdateMask: &fieldmaskpb.FieldMask{Paths: tc.paths}})
+			wantCode(t, err, tc.want)
+
+			details, err := s.reloadUserDetails(uint(target))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(details.GetRoles(), tc.wantRoles) {
+				t.Errorf("roles = %v, want %v", details.GetRoles(), tc.wantRoles)
+			}
+		})
+	}
+}
+
+// Deleting an account ends its sessions, its access tokens must not keep the
+// authorities they were issued with.
+func TestDeleteUser(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "admin")
+	s.assignRole(t, "admin", models.AdminRole)
+	adminCtx, _ := s.login(t, "admin", testPassword)
+
+	tests := []struct {
+		name string
+		hard bool
+	}{
+		{"soft", false},
+		{"hard", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s.register(t, tc.name)
+			s.assignRole(t, tc.name, models.AdminRole)
+			_, resp := s.login(t, tc.name, testPassword)
+
+			_, err := s.DeleteUser(adminCtx, &pb.DeleteUserRequest{UserId: uint64(s.userID(t, tc.name)), Hard: tc.hard})
+			wantCode(t, err, codes.OK)
+
+			if _, err := s.Manager.Verify(resp.GetAccessToken()); !errors.Is(err, manager.ErrTokenRevoked) {
+				t.Errorf("Verify of access token = %v, want %v", err, manager.ErrTokenRevoked)
+			}
+			if _, err := s.Manager.Refresh(resp.GetRefreshToken()); err == nil {
+				t.Error("refresh token still valid")
+			}
+		})
+	}
+}
+
+// Enabling undoes DisableUser only, it must not skip the verification of an
+// email address.
+func TestEnableUser(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "admin")
+	s.assignRole(t, "admin", models.AdminRole)
+	adminCtx, _ := s.login(t, "admin", testPassword)
+
+	tests := []struct {
+		name       string
+		email      string
+		verified   bool
+		disable    bool
+		wantStatus string
+	}{
+		{"active", "", false, false, models.UserStatusActive},
+		{"pending verification", "pending@example.com", false, false, models.UserStatusPendingVerification},
+		{"disabled", "", false, true, models.UserStatusActive},
+		{"disabled with unverified email", "unverified@example.com", false, true, models.UserStatusPendingVerification},
+		{"disabled with verified email", "verified@example.com", true, true, models.UserStatusActive},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.RegisterUser(context.Background(), &pb.RegisterUserRequest{Name: tc.name, Password: testPassword, Email: tc.email})
+			wantCode(t, err, codes.OK)
+			id := s.userID(t, tc.name)
+			if tc.verified {
+				if err := s.Users.Update(id, map[string]interface{}{"email_verified_at": time.Now()}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.disable {
+				_, err := s.DisableUser(adminCtx, &pb.DisableUserRequest{UserId: uint64(id)})
+				wantCode(t, err, codes.OK)
+			}
+
+			details, err := s.EnableUser(adminCtx, &pb.EnableUserRequest{UserId: uint64(id)})
+			wantCode(t, err, codes.OK)
+			if details.GetStatus() != tc.wantStatus {
+				t.Errorf("status = %s, want %s", details.GetStatus(), tc.wantStatus)
+			}
+		})
+	}
+}
@@ -1,16 +1,106 @@
 package api
 
 import (
+	"fmt"
+	"go-auth/server/config"
 	manager "go-auth/server/jwt"
+	"go-auth/server/lib/actiontoken"
+	"go-auth/server/lib/encryption"
+	"go-auth/server/lib/hasher"
+	"go-auth/server/lib/notifier"
+	"go-auth/server/lib/passwordpolicy"
+	"go-auth/server/lib/throttle"
 	"go-auth/server/pb"
+	"go-auth/server/repository"
 
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
 type Server struct {
 	pb.UserServiceServer
-	Db      *gorm.DB
+	pb.AdminServiceServer
+	// Runs changes spanning several repositories atomically.
+	Transactor repository.Transactor
+	// User accounts, roles and permissions.
+	Users   repository.UserRepository
+	Roles   repository.RoleRepository
 	Logger  *logrus.Logger
 	Manager *manager.JWTManager
+	// Settings in use, changed by reloads.
+	Config *config.Live
+	// Failed login back-off per client IP.
+	LoginThrottle *throttle.Limiter
+	// Failed login back-off of names without an account, kept like the
+	// lockout of accounts so unknown names cannot be told apart.
+	UnknownNameThrottle *throttle.Limiter
+	// Back-off of requests sending a message to an account, per client IP
+	// and per name, so nobody can flood a mailbox. They follow the policies
+	// of failed logins, counting every request.
+	MessageClientThrottle *throttle.Limiter
+	MessageNameThrottle   *throttle.Limiter
+	// Delivers password reset and email verification messages.
+	Notifier notifier.Notifier
+	// Single-use tokens of password resets and email verification.
+	ActionTokens *actiontoken.Manager
+	// Encrypts the MFA secrets of users.
+	MfaKey *encryption.Key
+	// Creates and verifies password hashes on the workers of HashPool.
+	Passwords *hasher.Registry
+	HashPool  *hasher.Pool
+	// Rules new passwords have to satisfy.
+	PasswordPolicy *passwordpolicy.Policy
+}
+
+// PasswordHashers returns the hasher registry configured by c. New hashes use
+// c.PasswordHasher, hashes of every other supported algorithm still verify.
+func PasswordHashers(c *config.Config) (*hasher.Registry, error) {
+	hashers := []hasher.Hasher{
+		hasher.Argon2id{
+			Memory:      uint32(c.Argon2Memory),
+			Iterations:  uint32(c.Argon2Iterations),
+			Parallelism: uint8(c.Argon2Parallelism),
+		},
+		hasher.Scrypt{LogN: c.ScryptCostLog2, R: hasher.ScryptBlockSize, P: 1},
+		hasher.Pbkdf2Sha256{Iterations: c.Pbkdf2Iterations},
+		hasher.Bcrypt{Cost: c.BcryptCost},
+	}
+
+	for i, h := range hashers {
+		if h.ID() == c.PasswordHasher {
+			others := append(append([]hasher.Hasher{}, hashers[:i]...), hashers[i+1:]...)
+			return hasher.NewRegistry(h, others...), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown password hasher %q", c.PasswordHasher)
+}
+
+// breachedPasswordsFalsePositiveRate is the share of passwords wrongly rejected
+// as breached, traded against the memory of the filter.
+const breachedPasswordsFalsePositiveRate = 0.001
+
+// PasswordPolicy returns the password policy configured by c, loading the
+// breached password corpus when one is configured.
+func PasswordPolicy(c *config.Config) (*passwordpolicy.Policy, error) {
+	policy := &passwordpolicy.Policy{
+		MinLength:      c.PasswordMinLength,
+		MaxBytes:       c.PasswordMaxBytes,
+		MinCharClasses: c.PasswordMinCharClasses,
+		ForbidUsername: c.PasswordForbidUsername,
+		MinEntropyBits: float64(c.PasswordMinEntropyBits),
+	}
+
+	if c.PasswordHasher == hasher.BcryptID && (policy.MaxBytes == 0 || policy.MaxBytes > hasher.BcryptMaxBytes) {
+		policy.MaxBytes = hasher.BcryptMaxBytes
+	}
+
+	if c.BreachedPasswordsFile != "" {
+		breached, err := passwordpolicy.LoadBloom(c.BreachedPasswordsFile, breachedPasswordsFalsePositiveRate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load breached passwords: %w", err)
+		}
+		policy.Breached = breached
+	}
+
+	return policy, nil
 }
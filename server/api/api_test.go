@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go-auth/server/config"
+	"go-auth/server/database"
+	"go-auth/server/database/databasetest"
+	manager "go-auth/server/jwt"
+	"go-auth/server/lib/actiontoken"
+	"go-auth/server/lib/encryption"
+	"go-auth/server/lib/hasher"
+	"go-auth/server/lib/notifier"
+	"go-auth/server/lib/throttle"
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"go-auth/server/repository"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testPassword = "Correct-Horse-77"
+
+// recordingNotifier keeps the messages sent, or fails them with err.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	msgs []notifier.Message
+	err  error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg notifier.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.err != nil {
+		return n.err
+	}
+	n.msgs = append(n.msgs, msg)
+	return nil
+}
+
+// last returns the value of key in the data of the last message.
+func (n *recordingNotifier) last(t *testing.T, key string) string {
+	t.Helper()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.msgs) == 0 {
+		t.Fatal("no message sent")
+	}
+	return n.msgs[len(n.msgs)-1].Data[key]
+}
+
+func (n *recordingNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.msgs)
+}
+
+type testServer struct {
+	*Server
+	notifier *recordingNotifier
+}
+
+// newTestServer returns a Server on a migrated SQLite database in a temporary
+// directory. configure changes the settings before the server is built.
+func newTestServer(t *testing.T, configure ...func(c *config.Config)) *testServer {
+	t.Helper()
+
+	c := config.Default()
+	c.AppKey = databasetest.AppKey
+	c.DatabaseDriver = database.SQLite
+	// Cheap hashes and no waiting keep the tests fast.
+	c.PasswordHasher = hasher.BcryptID
+	c.BcryptCost = bcrypt.MinCost
+	c.LoginBackoffBase = time.Millisecond
+	c.LoginBackoffMax = time.Millisecond
+	for _, fn := range configure {
+		fn(c)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+
+	db := migrationstest.Open(t)
+
+	transactor := repository.NewGormTransactor(db)
+	users := repository.NewGormUserRepository(db)
+	roles := repository.NewGormRoleRepository(db)
+	if err := SeedRoles(transactor, users, roles, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	passwords, err := PasswordHashers(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := PasswordPolicy(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwtManager := manager.NewJWTManager(
+		manager.NewHMACKeyRing(c.AppKey),
+		c.AccessTokenDuration,
+		c.RefreshTokenDuration,
+		manager.NewGormRefreshTokenStore(db),
+		manager.NewGormRevocationStore(db, time.Minute),
+		manager.NewGormAuthorityResolver(db),
+		logger,
+	)
+
+	mfaKey, err := encryption.NewKey(c.AppKey, models.MfaSecretPurpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &recordingNotifier{}
+	return &testServer{
+		Server: &Server{
+			Transactor:            transactor,
+			Users:                 users,
+			Roles:                 roles,
+			Logger:                logger,
+			Manager:               jwtManager,
+			Config:                config.NewLive(c),
+			LoginThrottle:         throttle.NewLimiter(ClientLoginPolicy(c)),
+			UnknownNameThrottle:   throttle.NewLimiter(AccountLoginPolicy(c)),
+			MessageClientThrottle: throttle.NewLimiter(ClientLoginPolicy(c)),
+			MessageNameThrottle:   throttle.NewLimiter(AccountLoginPolicy(c)),
+			Notifier:              n,
+			ActionTokens:          actiontoken.NewManager(db, []byte(c.AppKey)),
+			MfaKey:                mfaKey,
+			Passwords:             passwords,
+			HashPool:              hasher.NewPool(2, 16),
+			PasswordPolicy:        policy,
+		},
+		notifier: n,
+	}
+}
+
+// register creates an active user with testPassword.
+func (s *testServer) register(t *testing.T, name string) {
+	t.Helper()
+
+	if _, err := s.RegisterUser(context.Background(), &pb.RegisterUserRequest{Name: name, Password: testPassword}); err != nil {
+		t.Fatalf("RegisterUser(%s): %v", name, err)
+	}
+}
+
+// login returns an authenticated context of the user.
+func (s *testServer) login(t *testing.T, name, password string) (context.Context, *pb.LoginUserResponse) {
+	t.Helper()
+
+	resp, err := s.LoginUser(context.Background(), &pb.LoginUserRequest{Name: name, Password: password})
+	if err != nil {
+		t.Fatalf("LoginUser(%s): %v", name, err)
+	}
+
+	return s.authContext(t, resp.GetAccessToken()), resp
+}
+
+// authContext returns a context carrying the claims of accessToken, like the
+// auth interceptor does.
+func (s *testServer) authContext(t *testing.T, accessToken string) context.Context {
+	t.Helper()
+
+	claims, err := s.Manager.Verify(accessToken)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	return manager.ContextWithClaims(context.Background(), claims)
+}
+
+// hashesDone returns how many hashes the pool has run.
+func (s *testServer) hashesDone() int64 {
+	return s.HashPool.Metrics()["completed_total"].(int64)
+}
+
+// wantCode fails the test unless err carries the gRPC code want.
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+
+	if got := status.Code(err); got != want {
+		t.Fatalf("got code %s (%v), want %s", got, err, want)
+	}
+}
+
+// userID returns the internal id of the user with name.
+func (s *testServer) userID(t *testing.T, name string) uint {
+	t.Helper()
+
+	user, err := s.Users.FindByName(name)
+	if err != nil {
+		t.Fatalf("FindByName(%s): %v", name, err)
+	}
+
+	return user.ID
+}
+
+// assignRole gives the user with name the role.
+func (s *testServer) assignRole(t *testing.T, name, role string) {
+	t.Helper()
+
+	_, err := s.AssignRole(context.Background(), &pb.UserRoleRequest{UserId: uint64(s.userID(t, name)), Role: role})
+	if err != nil {
+		t.Fatalf("AssignRole(%s, %s): %v", name, role, err)
+	}
+}
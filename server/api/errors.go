@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"errors"
+	manager "go-auth/server/jwt"
+	"go-auth/server/lib/hasher"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+var (
+	errInvalidCredentials = status.Error(codes.Unauthenticated, "invalid credentials")
+	errUnauthenticated    = status.Error(codes.Unauthenticated, "Unauthorized")
+)
+
+// errorCodes maps errors of the storage and token layers to gRPC codes. Plain
+// errors that are not listed here are reported as INTERNAL.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{gorm.ErrRecordNotFound, codes.NotFound},
+	{gorm.ErrDuplicatedKey, codes.AlreadyExists},
+	{manager.ErrInvalidToken, codes.Unauthenticated},
+	{manager.ErrInvalidRefreshToken, codes.Unauthenticated},
+	{manager.ErrRefreshTokenReused, codes.Unauthenticated},
+	{manager.ErrRefreshTokenRevoked, codes.Unauthenticated},
+	{manager.ErrTokenRevoked, codes.Unauthenticated},
+	{manager.ErrInvalidMfaChallenge, codes.Unauthenticated},
+	{manager.ErrTokenNotOwned, codes.PermissionDenied},
+	{manager.ErrTokenNotRevocable, codes.InvalidArgument},
+	{hasher.ErrQueueFull, codes.ResourceExhausted},
+	{context.Canceled, codes.Canceled},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+}
+
+// field is a request field checked by requireFields.
+type field struct {
+	name  string
+	value string
+}
+
+// requireFields returns INVALID_ARGUMENT listing every empty field, or nil.
+func requireFields(fields ...field) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, f := range fields {
+		if f.value == "" {
+			violations = append(violations, fieldViolation(f.name, f.name+" is required"))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return invalidArgument(violations...)
+}
+
+func fieldViolation(field, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+// invalidArgument builds an INVALID_ARGUMENT status with a BadRequest detail.
+// The message is the description of the first violation.
+func invalidArgument(violations ...*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, violations[0].GetDescription())
+	withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// notFound reports gorm.ErrRecordNotFound as NOT_FOUND naming the missing
+// resource and returns other errors unchanged.
+func notFound(err error, resource string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, resource+" not found")
+	}
+
+	return err
+}
+
+// toStatusError converts a handler error into a gRPC status error. Errors that
+// already carry a status are returned unchanged.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	for _, mapping := range errorCodes {
+		if errors.Is(err, mapping.err) {
+			return status.Error(mapping.code, err.Error())
+		}
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// ErrorInterceptor makes sure every error leaving the server is a gRPC status.
+// Unexpected errors are logged and replaced by INTERNAL so database and library
+// messages do not reach clients.
+type ErrorInterceptor struct {
+	logger *logrus.Logger
+}
+
+func NewErrorInterceptor(logger *logrus.Logger) *ErrorInterceptor {
+	return &ErrorInterceptor{logger: logger}
+}
+
+func (interceptor *ErrorInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, interceptor.convert(info.FullMethod, err)
+		}
+
+		return resp, nil
+	}
+}
+
+func (interceptor *ErrorInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return interceptor.convert(info.FullMethod, handler(srv, stream))
+	}
+}
+
+func (interceptor *ErrorInterceptor) convert(method string, err error) error {
+	converted := toStatusError(err)
+	if status.Code(converted) == codes.Internal {
+		interceptor.logger.WithField("method", method).Error("Request failed: ", err)
+	}
+
+	return converted
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	manager "go-auth/server/jwt"
+	"go-auth/server/lib/hasher"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		want        codes.Code
+		wantMessage string
+	}{
+		{"nil", nil, codes.OK, ""},
+		{"status", status.Error(codes.FailedPrecondition, "mfa is not enabled"), codes.FailedPrecondition, "mfa is not enabled"},
+		{"record not found", gorm.ErrRecordNotFound, codes.NotFound, "record not found"},
+		{"wrapped duplicate", fmt.Errorf("creating user: %w", gorm.ErrDuplicatedKey), codes.AlreadyExists, "creating user: duplicated key not allowed"},
+		{"reused refresh token", manager.ErrRefreshTokenReused, codes.Unauthenticated, manager.ErrRefreshTokenReused.Error()},
+		{"token of another user", manager.ErrTokenNotOwned, codes.PermissionDenied, manager.ErrTokenNotOwned.Error()},
+		{"hashing queue full", hasher.ErrQueueFull, codes.ResourceExhausted, hasher.ErrQueueFull.Error()},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded, context.DeadlineExceeded.Error()},
+		{"unexpected error hides its message", errors.New("dial tcp 10.0.0.5:3306: connection refused"), codes.Internal, "internal error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.Convert(toStatusError(tc.err))
+			if st.Code() != tc.want || st.Message() != tc.wantMessage {
+				t.Errorf("toStatusError = %s %q, want %s %q", st.Code(), st.Message(), tc.want, tc.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRequireFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     []field
+		wantFields []string
+	}{
+		{"all set", []field{{"name", "alice"}, {"password", "secret"}}, nil},
+		{"one missing", []field{{"name", "alice"}, {"password", ""}}, []string{"password"}},
+		{"all missing", []field{{"name", ""}, {"password", ""}}, []string{"name", "password"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := requireFields(tc.fields...)
+			if tc.wantFields == nil {
+				if err != nil {
+					t.Errorf("requireFields = %v, want nil", err)
+				}
+				return
+			}
+
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument || st.Message() != tc.wantFields[0]+" is required" {
+				t.Errorf("requireFields = %s %q", st.Code(), st.Message())
+			}
+
+			var got []string
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					for _, violation := range badRequest.GetFieldViolations() {
+						got = append(got, violation.GetField())
+					}
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.wantFields) {
+				t.Errorf("violations of %v, want %v", got, tc.wantFields)
+			}
+		})
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"missing record", fmt.Errorf("find: %w", gorm.ErrRecordNotFound), status.Error(codes.NotFound, "role not found")},
+		{"other error", other, other},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := notFound(tc.err, "role")
+			if got.Error() != tc.want.Error() || status.Code(got) != status.Code(tc.want) {
+				t.Errorf("notFound = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestErrorInterceptor(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	interceptor := NewErrorInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.UserService/GetUser"}
+
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"success", nil, codes.OK},
+		{"status", status.Error(codes.NotFound, "user not found"), codes.NotFound},
+		{"mapped error", gorm.ErrRecordNotFound, codes.NotFound},
+		{"unexpected error", errors.New("disk full"), codes.Internal},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := interceptor.Unary()(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				if tc.err != nil {
+					return "partial", tc.err
+				}
+				return "ok", nil
+			})
+			if code := status.Code(err); code != tc.want {
+				t.Errorf("unary: code = %s, want %s", code, tc.want)
+			}
+			if (resp != nil) != (tc.err == nil) {
+				t.Errorf("unary: response %v with error %v", resp, err)
+			}
+
+			err = interceptor.Stream()(nil, nil, &grpc.StreamServerInfo{FullMethod: info.FullMethod}, func(srv interface{}, stream grpc.ServerStream) error {
+				return tc.err
+			})
+			if code := status.Code(err); code != tc.want {
+				t.Errorf("stream: code = %s, want %s", code, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"go-auth/server/config"
+	"go-auth/server/lib/throttle"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gorm.io/gorm"
+)
+
+const (
+	ReasonAccountLocked   = "ACCOUNT_LOCKED"
+	ReasonTooManyAttempts = "TOO_MANY_ATTEMPTS"
+)
+
+// AccountLoginPolicy is the back-off applied to failed logins of an account.
+func AccountLoginPolicy(c *config.Config) throttle.Policy {
+	return throttle.Policy{
+		FreeAttempts:     c.LoginFreeAttempts,
+		BackoffBase:      c.LoginBackoffBase,
+		BackoffMax:       c.LoginBackoffMax,
+		LockoutThreshold: c.LoginMaxAttempts,
+		LockoutDuration:  c.LoginLockoutDuration,
+	}
+}
+
+// ClientLoginPolicy is the back-off applied to failed logins from a client IP.
+func ClientLoginPolicy(c *config.Config) throttle.Policy {
+	return throttle.Policy{
+		FreeAttempts:     c.LoginFreeAttempts,
+		BackoffBase:      c.LoginBackoffBase,
+		BackoffMax:       c.LoginBackoffMax,
+		LockoutThreshold: c.LoginMaxAttemptsPerIp,
+		LockoutDuration:  c.LoginLockoutDuration,
+	}
+}
+
+func (s *Server) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.DefaultResponse, error) {
+	user, err := s.findUser(s.Users.FindByID, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Users.Update(user.ID, clearedFailures()); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Unlocked user: ", req.GetUserId())
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+// checkClientThrottle rejects the request while the calling IP is backing off.
+func (s *Server) checkClientThrottle(ctx context.Context) error {
+	if wait := s.LoginThrottle.Check(clientIP(ctx)); wait > 0 {
+		return throttledError(ReasonTooManyAttempts, wait)
+	}
+
+	return nil
+}
+
+func (s *Server) recordClientFailure(ctx context.Context) {
+	s.LoginThrottle.Failure(clientIP(ctx))
+}
+
+// checkMessageThrottle rejects a request sending a message to the account
+// with name while the client or the name is backing off, and counts it
+// otherwise. Every request counts, whether or not the account exists, so the
+// answer tells nothing about it.
+func (s *Server) checkMessageThrottle(ctx context.Context, name string) error {
+	ip := clientIP(ctx)
+
+	wait := s.MessageClientThrottle.Check(ip)
+	if nameWait := s.MessageNameThrottle.Check(name); nameWait > wait {
+		wait = nameWait
+	}
+	if wait > 0 {
+		return throttledError(ReasonTooManyAttempts, wait)
+	}
+
+	s.MessageClientThrottle.Failure(ip)
+	s.MessageNameThrottle.Failure(name)
+
+	return nil
+}
+
+// checkAccountLock rejects the request while the account is backing off or locked.
+func (s *Server) checkAccountLock(user *models.User) error {
+	if user.LockedUntil == nil {
+		return nil
+	}
+
+	return s.lockError(time.Until(*user.LockedUntil), user.FailedLoginCount)
+}
+
+// checkUnknownNameLock rejects the request while a name without an account is
+// backing off or locked, with the same answer as for an account.
+func (s *Server) checkUnknownNameLock(name string) error {
+	return s.lockError(s.UnknownNameThrottle.Check(name), s.UnknownNameThrottle.Failures(name))
+}
+
+// recordUnknownNameFailure counts a failed login of a name without an account
+// like recordAccountFailure counts it for an account.
+func (s *Server) recordUnknownNameFailure(name string) {
+	s.UnknownNameThrottle.Failure(name)
+}
+
+// lockError returns the throttled error of a login blocked for wait after the
+// given number of failures, nil when wait has passed.
+func (s *Server) lockError(wait time.Duration, failures int) error {
+	if wait <= 0 {
+		return nil
+	}
+
+	reason := ReasonTooManyAttempts
+	if failures >= s.Config.Get().LoginMaxAttempts {
+		reason = ReasonAccountLocked
+	}
+
+	return throttledError(reason, wait)
+}
+
+// recordAccountFailure counts a failed login against the account and blocks it
+// for the back-off of the new failure count.
+func (s *Server) recordAccountFailure(user *models.User) error {
+	settings := s.Config.Get()
+	policy := AccountLoginPolicy(settings)
+	now := time.Now()
+
+	return s.Transactor.Transaction(func(tx *gorm.DB) error {
+		users := s.Users.WithTx(tx)
+
+		failures, err := users.RecordLoginFailure(user.ID, now, policy.LockoutDuration)
+		if err != nil {
+			return err
+		}
+
+		lockedUntil := now.Add(policy.Delay(failures))
+		if failures >= settings.LoginMaxAttempts {
+			s.Logger.Warn("Account locked after repeated login failures: ", user.ID)
+		}
+
+		return users.Update(user.ID, map[string]interface{}{"locked_until": lockedUntil})
+	})
+}
+
+func (s *Server) resetAccountFailures(user *models.User) error {
+	if user.FailedLoginCount == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	return s.Users.Update(user.ID, clearedFailures())
+}
+
+// clearedFailures are the user columns ending a login back-off or lockout.
+func clearedFailures() map[string]interface{} {
+	return map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+	}
+}
+
+// throttledError builds a RESOURCE_EXHAUSTED status carrying RetryInfo so
+// clients can tell the user when to try again.
+func throttledError(reason string, wait time.Duration) error {
+	seconds := int64(math.Ceil(wait.Seconds()))
+
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("too many failed attempts, try again in %d seconds", seconds))
+	st, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: reason, Domain: "go-auth"},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(seconds) * time.Second)},
+	)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "too many failed attempts")
+	}
+
+	return st.Err()
+}
+
+// clientIP returns the address of the caller. X-Forwarded-For is only trusted
+// when the request comes from the local REST gateway, which appends the HTTP
+// peer address as the last entry.
+func clientIP(ctx context.Context) string {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.IsLoopback() {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+				hops := strings.Split(forwarded[len(forwarded)-1], ",")
+				ip = strings.TrimSpace(hops[len(hops)-1])
+			}
+		}
+	}
+
+	return ip
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go-auth/server/config"
+	"go-auth/server/pb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// fromIP returns a context of a call from ip carrying the given metadata.
+func fromIP(ip string, pairs ...string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+	if len(pairs) > 0 {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(pairs...))
+	}
+
+	return ctx
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no peer", context.Background(), ""},
+		{"remote peer", fromIP("203.0.113.7"), "203.0.113.7"},
+		{"ipv6 peer", fromIP("2001:db8::1"), "2001:db8::1"},
+		{"remote peer ignores forwarded for", fromIP("203.0.113.7", "x-forwarded-for", "198.51.100.1"), "203.0.113.7"},
+		{"gateway without forwarded for", fromIP("127.0.0.1"), "127.0.0.1"},
+		{"gateway", fromIP("127.0.0.1", "x-forwarded-for", "198.51.100.1"), "198.51.100.1"},
+		{"gateway behind proxies", fromIP("127.0.0.1", "x-forwarded-for", "10.0.0.1, 198.51.100.1"), "198.51.100.1"},
+		{"ipv6 gateway", fromIP("::1", "x-forwarded-for", "198.51.100.1"), "198.51.100.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := clientIP(tc.ctx); got != tc.want {
+				t.Errorf("clientIP = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestThrottledError(t *testing.T) {
+	tests := []struct {
+		name      string
+		reason    string
+		wait      time.Duration
+		wantDelay time.Duration
+	}{
+		{"whole seconds", ReasonAccountLocked, 3 * time.Second, 3 * time.Second},
+		{"rounded up", ReasonTooManyAttempts, 1500 * time.Millisecond, 2 * time.Second},
+		{"below a second", ReasonTooManyAttempts, time.Millisecond, time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.Convert(throttledError(tc.reason, tc.wait))
+			if st.Code() != codes.ResourceExhausted {
+				t.Errorf("code = %s", st.Code())
+			}
+
+			var reason string
+			var delay time.Duration
+			for _, detail := range st.Details() {
+				switch detail := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = detail.GetReason()
+				case *errdetails.RetryInfo:
+					delay = detail.GetRetryDelay().AsDuration()
+				}
+			}
+			if reason != tc.reason || delay != tc.wantDelay {
+				t.Errorf("reason %s, retry delay %s, want %s, %s", reason, delay, tc.reason, tc.wantDelay)
+			}
+		})
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 0
+		c.LoginMaxAttempts = 2
+		c.LoginMaxAttemptsPerIp = 100
+		c.LoginLockoutDuration = time.Hour
+	})
+	s.register(t, "alice")
+	s.register(t, "bob")
+
+	login := func(name, password string) func() error {
+		return func() error {
+			_, err := s.LoginUser(context.Background(), &pb.LoginUserRequest{Name: name, Password: password})
+			// Let the back-off of a failure pass.
+			time.Sleep(5 * time.Millisecond)
+			return err
+		}
+	}
+	unlock := func() error {
+		_, err := s.UnlockUser(context.Background(), &pb.UnlockUserRequest{UserId: uint64(s.userID(t, "alice"))})
+		return err
+	}
+	locked := codes.ResourceExhausted.String() + " " + ReasonAccountLocked
+
+	steps := []struct {
+		name   string
+		action func() error
+		want   string
+	}{
+		{"first failure", login("alice", "Wrong-Horse-77"), codes.Unauthenticated.String()},
+		{"success resets the count", login("alice", testPassword), codes.OK.String()},
+		{"failure after reset", login("alice", "Wrong-Horse-77"), codes.Unauthenticated.String()},
+		{"failure reaching the limit", login("alice", "Wrong-Horse-77"), codes.Unauthenticated.String()},
+		{"locked with wrong password", login("alice", "Wrong-Horse-77"), locked},
+		{"locked with right password", login("alice", testPassword), locked},
+		{"other account", login("bob", testPassword), codes.OK.String()},
+		{"unlock", unlock, codes.OK.String()},
+		{"unlocked", login("alice", testPassword), codes.OK.String()},
+	}
+
+	for _, step := range steps {
+		if got := answer(step.action()); got != step.want {
+			t.Fatalf("%s: got %s, want %s", step.name, got, step.want)
+		}
+	}
+}
+
+// A client is throttled after too many failures, whichever names it tries.
+func TestClientThrottle(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 0
+		c.LoginMaxAttempts = 100
+		c.LoginMaxAttemptsPerIp = 2
+		c.LoginLockoutDuration = time.Hour
+	})
+	s.register(t, "alice")
+	attacker := fromIP("203.0.113.7")
+	other := fromIP("198.51.100.1")
+	throttled := codes.ResourceExhausted.String() + " " + ReasonTooManyAttempts
+
+	steps := []struct {
+		name     string
+		ctx      context.Context
+		user     string
+		password string
+		want     string
+	}{
+		{"first failure", attacker, "alice", "Wrong-Horse-77", codes.Unauthenticated.String()},
+		{"unknown name", attacker, "bob", "Wrong-Horse-77", codes.Unauthenticated.String()},
+		{"throttled", attacker, "carol", "Wrong-Horse-77", throttled},
+		{"throttled with right password", attacker, "alice", testPassword, throttled},
+		{"other client", other, "alice", testPassword, codes.OK.String()},
+	}
+
+	for _, step := range steps {
+		_, err := s.LoginUser(step.ctx, &pb.LoginUserRequest{Name: step.user, Password: step.password})
+		time.Sleep(5 * time.Millisecond)
+		if got := answer(err); got != step.want {
+			t.Fatalf("%s: got %s, want %s", step.name, got, step.want)
+		}
+	}
+}
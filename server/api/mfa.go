@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	manager "go-auth/server/jwt"
+	"go-auth/server/lib/totp"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// Accept codes from one period before and after the current one.
+	totpSkew = 1
+)
+
+func (s *Server) EnrollMfa(ctx context.Context, req *pb.Empty) (*pb.EnrollMfaResponse, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MfaEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "mfa is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := s.MfaKey.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Users.Update(user.ID, map[string]interface{}{
+		"mfa_secret":    encrypted,
+		"mfa_last_step": 0,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &pb.EnrollMfaResponse{
+		Secret:     secret,
+		OtpauthUri: totp.URI(s.Config.Get().AppName, user.Name, secret),
+	}, nil
+}
+
+func (s *Server) ConfirmMfa(ctx context.Context, req *pb.ConfirmMfaRequest) (*pb.ConfirmMfaResponse, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MfaEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "mfa is already enabled")
+	}
+
+	if user.MfaSecret == "" {
+		return nil, status.Error(codes.FailedPrecondition, "mfa enrollment has not been started")
+	}
+
+	if err := s.checkTotp(user, req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Transactor.Transaction(func(tx *gorm.DB) error {
+		users := s.Users.WithTx(tx)
+		if err := users.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+			return err
+		}
+
+		return users.Update(user.ID, map[string]interface{}{"mfa_enabled": true})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("MFA enabled for user: ", user.ID)
+
+	return &pb.ConfirmMfaResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *Server) VerifyMfa(ctx context.Context, req *pb.VerifyMfaRequest) (*pb.LoginUserResponse, error) {
+	claims, err := s.Manager.VerifyMfaChallenge(req.GetMfaToken())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetCode() == "" && req.GetRecoveryCode() == "" {
+		return nil, invalidArgument(fieldViolation("code", "code or recovery_code is required"))
+	}
+
+	user, err := s.Users.FindByPublicID(claims.UserId)
+	if err != nil {
+		return nil, notFound(err, "user")
+	}
+
+	if !user.MfaEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "mfa is not enabled")
+	}
+
+	if err := s.checkUserActive(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAccountLock(user); err != nil {
+		return nil, err
+	}
+
+	if req.GetCode() != "" {
+		err = s.checkTotp(user, req.GetCode())
+	} else {
+		err = s.useRecoveryCode(user, req.GetRecoveryCode())
+	}
+	if status.Code(err) == codes.InvalidArgument {
+		s.Logger.Warn("MFA verification failed for user: ", user.ID)
+		if err := s.recordAccountFailure(user); err != nil {
+			return nil, err
+		}
+		// Wrong codes fail the login as a whole, like a wrong password.
+		return nil, status.Error(codes.Unauthenticated, "invalid mfa code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.resetAccountFailures(user); err != nil {
+		return nil, err
+	}
+
+	// The challenge is single use.
+	if err := s.Manager.RevokeClaims(claims); err != nil {
+		return nil, err
+	}
+
+	jwtToken, err := s.Manager.Generate(claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return loginResponse(jwtToken), nil
+}
+
+func (s *Server) DisableMfa(ctx context.Context, req *pb.DisableMfaRequest) (*pb.DefaultResponse, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MfaEnabled {
+		return nil, status.Error(codes.FailedPrecondition, "mfa is not enabled")
+	}
+
+	if err := s.checkTotp(user, req.GetCode()); err != nil {
+		return nil, err
+	}
+
+	err = s.Transactor.Transaction(func(tx *gorm.DB) error {
+		users := s.Users.WithTx(tx)
+		if err := users.ReplaceRecoveryCodes(user.ID, nil); err != nil {
+			return err
+		}
+
+		return users.Update(user.ID, map[string]interface{}{
+			"mfa_enabled":   false,
+			"mfa_secret":    "",
+			"mfa_last_step": 0,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("MFA disabled for user: ", user.ID)
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+// checkTotp validates a TOTP code and records its time step so the same code
+// cannot be replayed. Rejected codes are reported as INVALID_ARGUMENT.
+func (s *Server) checkTotp(user *models.User, code string) error {
+	secret, err := s.MfaKey.Decrypt(user.MfaSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return invalidArgument(fieldViolation("code", "invalid mfa code"))
+	}
+
+	advanced, err := s.Users.AdvanceMfaStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return invalidArgument(fieldViolation("code", "mfa code has already been used"))
+	}
+
+	return nil
+}
+
+func (s *Server) useRecoveryCode(user *models.User, code string) error {
+	used, err := s.Users.UseRecoveryCode(user.ID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalidArgument(fieldViolation("recovery_code", "invalid recovery code"))
+	}
+
+	s.Logger.Info("Recovery code used by user: ", user.ID)
+
+	return nil
+}
+
+// currentUser loads the user of the verified access token.
+func (s *Server) currentUser(ctx context.Context) (*models.User, error) {
+	claims, ok := manager.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	user, err := s.Users.FindByPublicID(claims.UserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = alphabet[b[j]%32]
+		}
+
+		code := string(b[:5]) + "-" + string(b[5:])
+		recoveryCodes = append(recoveryCodes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return recoveryCodes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
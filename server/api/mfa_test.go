@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-auth/server/lib/encryption"
+	"go-auth/server/lib/totp"
+	"go-auth/server/pb"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestMfa(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	ctx, _ := s.login(t, "alice", testPassword)
+
+	enrollment, err := s.EnrollMfa(ctx, &pb.Empty{})
+	wantCode(t, err, codes.OK)
+
+	// The shared secret is only stored encrypted.
+	user, err := s.Users.FindByName("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encryption.IsEncrypted(user.MfaSecret) {
+		t.Errorf("stored mfa secret %q is not encrypted", user.MfaSecret)
+	}
+
+	code, err := totp.Code(enrollment.GetSecret(), totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recoveryCodes []string
+	confirmTests := []struct {
+		name string
+		code string
+		want codes.Code
+	}{
+		{"wrong code", "000000", codes.InvalidArgument},
+		{"current code", code, codes.OK},
+		{"already enabled", code, codes.FailedPrecondition},
+	}
+	for _, tc := range confirmTests {
+		t.Run("confirm "+tc.name, func(t *testing.T) {
+			resp, err := s.ConfirmMfa(ctx, &pb.ConfirmMfaRequest{Code: tc.code})
+			wantCode(t, err, tc.want)
+			if err == nil {
+				recoveryCodes = resp.GetRecoveryCodes()
+			}
+		})
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+
+	verifyTests := []struct {
+		name         string
+		code         string
+		recoveryCode string
+		want         codes.Code
+	}{
+		{"no code", "", "", codes.InvalidArgument},
+		{"replayed code", code, "", codes.Unauthenticated},
+		{"recovery code", "", recoveryCodes[0], codes.OK},
+		{"used recovery code", "", recoveryCodes[0], codes.Unauthenticated},
+	}
+	for _, tc := range verifyTests {
+		t.Run("verify "+tc.name, func(t *testing.T) {
+			login, err := s.LoginUser(context.Background(), &pb.LoginUserRequest{Name: "alice", Password: testPassword})
+			wantCode(t, err, codes.OK)
+			if !login.GetMfaRequired() {
+				t.Fatal("login does not require mfa")
+			}
+
+			resp, err := s.VerifyMfa(context.Background(), &pb.VerifyMfaRequest{MfaToken: login.GetMfaToken(), Code: tc.code, RecoveryCode: tc.recoveryCode})
+			wantCode(t, err, tc.want)
+			if err == nil && resp.GetAccessToken() == "" {
+				t.Error("no access token issued")
+			}
+		})
+	}
+}
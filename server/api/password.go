@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-auth/server/lib/actiontoken"
+	"go-auth/server/lib/notifier"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"gorm.io/gorm"
+)
+
+// Purpose of the action tokens sent by RequestPasswordReset.
+const actionPasswordReset = "password_reset"
+
+var errInvalidResetToken = invalidArgument(fieldViolation("token", "invalid or expired password reset token"))
+
+// ChangePassword replaces the password of the current user. Every session is
+// ended, its refresh and access tokens are revoked, and the caller receives a
+// new token pair.
+func (s *Server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.LoginUserResponse, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireFields(
+		field{"current_password", req.GetCurrentPassword()},
+		field{"new_password", req.GetNewPassword()},
+	); err != nil {
+		return nil, err
+	}
+
+	ok, _, err := s.VerifyPassword(ctx, req.GetCurrentPassword(), user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, invalidArgument(fieldViolation("current_password", "invalid password"))
+	}
+
+	if err := s.checkPasswordPolicy(req.GetNewPassword(), user.Name, "new_password"); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.HashPassword(ctx, req.GetNewPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Users.Update(user.ID, map[string]interface{}{"password": hashedPassword}); err != nil {
+		return nil, err
+	}
+
+	if err := s.Manager.RevokeUserSessions(user.PublicId); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Password changed for user: ", user.ID)
+
+	jwtToken, err := s.Manager.Generate(user.PublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	return loginResponse(jwtToken), nil
+}
+
+// RequestPasswordReset sends a single-use reset token to the user. The response
+// is the same whether or not the account exists.
+func (s *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.DefaultResponse, error) {
+	if err := requireFields(field{"name", req.GetName()}); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMessageThrottle(ctx, req.GetName()); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "If the account exists a password reset message has been sent",
+	}
+
+	user, err := s.GetUserByName(ctx, req.GetName())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == models.UserStatusDisabled {
+		s.Logger.Info("Password reset requested for disabled user: ", user.ID)
+		return resp, nil
+	}
+
+	token, err := s.ActionTokens.Issue(
+		actionPasswordReset,
+		user.ID,
+		strconv.FormatUint(uint64(user.ID), 10),
+		s.Config.Get().PasswordResetTokenDuration,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failure is only logged, an error would tell that the account exists.
+	if err := s.Notifier.Notify(ctx, s.passwordResetMessage(user, token)); err != nil {
+		s.Logger.Error("Failed to send password reset message: ", err)
+		return resp, nil
+	}
+
+	s.Logger.Info("Password reset requested for user: ", user.ID)
+
+	return resp, nil
+}
+
+// ConfirmPasswordReset sets a new password using a token from
+// RequestPasswordReset. All sessions of the user are revoked and any login
+// lockout is cleared.
+func (s *Server) ConfirmPasswordReset(ctx context.Context, req *pb.ConfirmPasswordResetRequest) (*pb.DefaultResponse, error) {
+	if err := requireFields(
+		field{"token", req.GetToken()},
+		field{"new_password", req.GetNewPassword()},
+	); err != nil {
+		return nil, err
+	}
+
+	// The token is checked before the password is hashed, so invalid tokens
+	// cost no hashing. The token subject is the internal id of the user.
+	userID, err := s.ActionTokens.Peek(actionPasswordReset, req.GetToken())
+	if errors.Is(err, actiontoken.ErrInvalidToken) {
+		return nil, errInvalidResetToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseUint(userID, 10, strconv.IntSize)
+	if err != nil {
+		return nil, errInvalidResetToken
+	}
+	user, err := s.Users.FindByID(uint(id))
+	if err != nil {
+		return nil, notFound(err, "user")
+	}
+
+	// A rejected password leaves the token usable.
+	if err := s.checkPasswordPolicy(req.GetNewPassword(), user.Name, "new_password"); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.HashPassword(ctx, req.GetNewPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Transactor.Transaction(func(tx *gorm.DB) error {
+		tokens := s.ActionTokens.WithTx(tx)
+		users := s.Users.WithTx(tx)
+
+		// The token may have been used while the password was hashed.
+		subject, err := tokens.Consume(actionPasswordReset, req.GetToken())
+		if errors.Is(err, actiontoken.ErrInvalidToken) {
+			return errInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		if subject != userID {
+			return errInvalidResetToken
+		}
+
+		// Outstanding tokens of the user are spent as well.
+		if err := tokens.RevokeAll(actionPasswordReset, user.ID); err != nil {
+			return err
+		}
+
+		updates := clearedFailures()
+		updates["password"] = hashedPassword
+		return users.Update(user.ID, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Manager.RevokeUserSessions(user.PublicId); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Password reset for user: ", userID)
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+// checkPasswordPolicy rejects a new password of the user with the given name
+// that breaks the password policy, with a violation of fieldName per broken
+// rule.
+func (s *Server) checkPasswordPolicy(password, username, fieldName string) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, description := range s.PasswordPolicy.Check(password, username) {
+		violations = append(violations, fieldViolation(fieldName, description))
+	}
+
+	if len(violations) > 0 {
+		return invalidArgument(violations...)
+	}
+
+	return nil
+}
+
+func (s *Server) passwordResetMessage(user *models.User, token string) notifier.Message {
+	body := fmt.Sprintf("Use this token to reset your password: %s", token)
+	if s.Config.Get().PasswordResetUrl != "" {
+		body = fmt.Sprintf("Open this link to reset your password: %s",
+			strings.Replace(s.Config.Get().PasswordResetUrl, "%s", token, 1))
+	}
+
+	return notifier.Message{
+		To:      recipient(user),
+		Subject: "Reset your password",
+		Body:    body,
+		Data: map[string]string{
+			"token":      token,
+			"expires_in": s.Config.Get().PasswordResetTokenDuration.String(),
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-auth/server/config"
+	"go-auth/server/pb"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestChangePassword(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	ctx, _ := s.login(t, "alice", testPassword)
+
+	tests := []struct {
+		name    string
+		current string
+		next    string
+		want    codes.Code
+	}{
+		{"missing fields", "", "", codes.InvalidArgument},
+		{"wrong current password", "Wrong-Horse-77", "Another-Good-42", codes.InvalidArgument},
+		{"weak new password", testPassword, "short", codes.InvalidArgument},
+		{"success", testPassword, "Another-Good-42", codes.OK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{CurrentPassword: tc.current, NewPassword: tc.next})
+			wantCode(t, err, tc.want)
+		})
+	}
+
+	s.login(t, "alice", "Another-Good-42")
+}
+
+// Changing the password ends the other sessions at once, not only when their
+// access tokens expire.
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	ctx, _ := s.login(t, "alice", testPassword)
+	_, other := s.login(t, "alice", testPassword)
+
+	// Revocations cover the tokens issued before the current second.
+	time.Sleep(time.Second)
+
+	resp, err := s.ChangePassword(ctx, &pb.ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "Another-Good-42"})
+	wantCode(t, err, codes.OK)
+
+	if _, err := s.Manager.Verify(other.GetAccessToken()); err == nil {
+		t.Error("access token of another session is still valid")
+	}
+	if _, err := s.Manager.Refresh(other.GetRefreshToken()); err == nil {
+		t.Error("refresh token of another session is still valid")
+	}
+	if _, err := s.Manager.Verify(resp.GetAccessToken()); err != nil {
+		t.Errorf("new access token is rejected: %v", err)
+	}
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+
+	_, err := s.RequestPasswordReset(context.Background(), &pb.RequestPasswordResetRequest{Name: "alice"})
+	wantCode(t, err, codes.OK)
+	token := s.notifier.last(t, "token")
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		want     codes.Code
+	}{
+		{"forged token", "nonce.signature", "Another-Good-42", codes.InvalidArgument},
+		{"tampered token", token + "x", "Another-Good-42", codes.InvalidArgument},
+		{"weak password keeps the token", token, "short", codes.InvalidArgument},
+		{"success", token, "Another-Good-42", codes.OK},
+		{"token used twice", token, "Third-Good-Pass-9", codes.InvalidArgument},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{Token: tc.token, NewPassword: tc.password})
+			wantCode(t, err, tc.want)
+		})
+	}
+
+	s.login(t, "alice", "Another-Good-42")
+}
+
+// Unauthenticated callers must not be able to make the server hash passwords
+// with made up tokens.
+func TestConfirmPasswordResetHashesOnlyForValidTokens(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	before := s.hashesDone()
+
+	for _, token := range []string{"junk", "nonce.signature", ""} {
+		s.ConfirmPasswordReset(context.Background(), &pb.ConfirmPasswordResetRequest{Token: token, NewPassword: "Another-Good-42"})
+	}
+
+	if done := s.hashesDone() - before; done != 0 {
+		t.Errorf("%d passwords hashed for invalid tokens", done)
+	}
+}
+
+// The response must not tell whether the account exists, even when the
+// message cannot be sent.
+func TestRequestPasswordResetHidesAccounts(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 10
+		c.LoginMaxAttempts = 20
+	})
+	s.register(t, "alice")
+
+	tests := []struct {
+		name        string
+		user        string
+		notifierErr error
+	}{
+		{"existing user", "alice", nil},
+		{"unknown user", "bob", nil},
+		{"existing user, notifier down", "alice", errors.New("notifier down")},
+		{"unknown user, notifier down", "bob", errors.New("notifier down")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s.notifier.err = tc.notifierErr
+			resp, err := s.RequestPasswordReset(context.Background(), &pb.RequestPasswordResetRequest{Name: tc.user})
+			wantCode(t, err, codes.OK)
+			if resp.GetMessage() != "If the account exists a password reset message has been sent" {
+				t.Errorf("message = %q", resp.GetMessage())
+			}
+		})
+	}
+}
+
+// Reset messages are throttled per name and per client like verification
+// messages.
+func TestRequestPasswordResetThrottle(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 0
+		c.LoginMaxAttempts = 2
+		c.LoginMaxAttemptsPerIp = 3
+		c.LoginLockoutDuration = time.Hour
+	})
+	s.register(t, "alice")
+	attacker := fromIP("203.0.113.7")
+	other := fromIP("198.51.100.1")
+	throttled := codes.ResourceExhausted.String() + " " + ReasonTooManyAttempts
+
+	steps := []struct {
+		name string
+		ctx  context.Context
+		user string
+		want string
+	}{
+		{"first message", attacker, "alice", codes.OK.String()},
+		{"second message", attacker, "alice", codes.OK.String()},
+		{"name throttled", attacker, "alice", throttled},
+		{"name throttled for other clients", other, "alice", throttled},
+		{"unknown name", attacker, "nobody", codes.OK.String()},
+		{"client throttled", attacker, "carol", throttled},
+		{"other client", other, "carol", codes.OK.String()},
+	}
+
+	for _, step := range steps {
+		_, err := s.RequestPasswordReset(step.ctx, &pb.RequestPasswordResetRequest{Name: step.user})
+		time.Sleep(5 * time.Millisecond)
+		if got := answer(err); got != step.want {
+			t.Fatalf("%s: got %s, want %s", step.name, got, step.want)
+		}
+	}
+}
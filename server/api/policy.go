@@ -0,0 +1,59 @@
+package api
+
+import (
+	manager "go-auth/server/jwt"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// MethodPolicies lists the access policy of every exposed RPC. The auth
+// interceptor rejects methods that are missing here, so new RPCs must be added
+// to this table.
+func MethodPolicies() map[string]manager.Policy {
+	manageRoles := manager.RequirePermission(models.PermissionManageRoles)
+	manageUsers := manager.RequirePermission(models.PermissionManageUsers)
+
+	return map[string]manager.Policy{
+		pb.UserService_RegisterUser_FullMethodName: manager.PublicPolicy,
+		pb.UserService_LoginUser_FullMethodName:    manager.PublicPolicy,
+		pb.UserService_RefreshToken_FullMethodName: manager.PublicPolicy,
+		pb.UserService_GetUser_FullMethodName:      manager.AuthenticatedPolicy,
+		pb.UserService_Logout_FullMethodName:       manager.AuthenticatedPolicy,
+		pb.UserService_RevokeToken_FullMethodName:  manager.AuthenticatedPolicy,
+		pb.UserService_EnrollMfa_FullMethodName:    manager.AuthenticatedPolicy,
+		pb.UserService_ConfirmMfa_FullMethodName:   manager.AuthenticatedPolicy,
+		pb.UserService_VerifyMfa_FullMethodName:    manager.PublicPolicy,
+		pb.UserService_DisableMfa_FullMethodName:   manager.AuthenticatedPolicy,
+
+		pb.UserService_ChangePassword_FullMethodName:       manager.AuthenticatedPolicy,
+		pb.UserService_RequestPasswordReset_FullMethodName: manager.PublicPolicy,
+		pb.UserService_ConfirmPasswordReset_FullMethodName: manager.PublicPolicy,
+
+		pb.UserService_GetMe_FullMethodName:    manager.AuthenticatedPolicy,
+		pb.UserService_UpdateMe_FullMethodName: manager.AuthenticatedPolicy,
+
+		pb.UserService_SendVerification_FullMethodName: manager.PublicPolicy,
+		pb.UserService_VerifyEmail_FullMethodName:      manager.PublicPolicy,
+
+		pb.AdminService_CreateRole_FullMethodName:       manageRoles,
+		pb.AdminService_ListRoles_FullMethodName:        manageRoles,
+		pb.AdminService_DeleteRole_FullMethodName:       manageRoles,
+		pb.AdminService_CreatePermission_FullMethodName: manageRoles,
+		pb.AdminService_ListPermissions_FullMethodName:  manageRoles,
+		pb.AdminService_GrantPermission_FullMethodName:  manageRoles,
+		pb.AdminService_RevokePermission_FullMethodName: manageRoles,
+		pb.AdminService_AssignRole_FullMethodName:       manageRoles,
+		pb.AdminService_UnassignRole_FullMethodName:     manageRoles,
+		pb.AdminService_UnlockUser_FullMethodName:       manageUsers,
+		pb.AdminService_ListUsers_FullMethodName:        manageUsers,
+		pb.AdminService_UpdateUser_FullMethodName:       manageUsers,
+		pb.AdminService_DisableUser_FullMethodName:      manageUsers,
+		pb.AdminService_EnableUser_FullMethodName:       manageUsers,
+		pb.AdminService_DeleteUser_FullMethodName:       manageUsers,
+
+		healthgrpc.Health_Check_FullMethodName: manager.PublicPolicy,
+		healthgrpc.Health_Watch_FullMethodName: manager.PublicPolicy,
+	}
+}
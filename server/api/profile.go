@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	// Time zones are validated against the embedded database so the result
+	// does not depend on the zoneinfo files of the host.
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	maxEmailLength       = 254
+	maxDisplayNameLength = 100
+	maxAvatarUrlLength   = 2048
+)
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// profileMessage is implemented by the messages carrying profile fields,
+// pb.Profile and pb.UserDetails.
+type profileMessage interface {
+	GetEmail() string
+	GetDisplayName() string
+	GetAvatarUrl() string
+	GetLocale() string
+	GetTimezone() string
+	GetPhone() string
+}
+
+func (s *Server) GetMe(ctx context.Context, req *pb.Empty) (*pb.Profile, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbProfile(user), nil
+}
+
+// UpdateMe changes the profile fields of the caller listed in the update mask.
+func (s *Server) UpdateMe(ctx context.Context, req *pb.UpdateMeRequest) (*pb.Profile, error) {
+	if req.GetProfile() == nil {
+		return nil, invalidArgument(fieldViolation("profile", "profile is required"))
+	}
+
+	if len(req.GetUpdateMask().GetPaths()) == 0 {
+		return nil, invalidArgument(fieldViolation("update_mask", "update_mask is required"))
+	}
+
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		if path == "id" {
+			continue
+		}
+
+		ok, err := s.profileUpdate(user, path, req.GetProfile(), "profile.", updates)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, invalidArgument(fieldViolation("update_mask", "unsupported path "+path))
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.Users.Update(user.ID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err = s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbProfile(user), nil
+}
+
+// profileUpdate validates the new value of the profile field at path and adds
+// the columns to store to updates. It returns false when path is not a profile
+// field. prefix is prepended to field names in validation errors.
+func (s *Server) profileUpdate(user *models.User, path string, msg profileMessage, prefix string, updates map[string]interface{}) (bool, error) {
+	invalid := func(description string) error {
+		return invalidArgument(fieldViolation(prefix+path, description))
+	}
+
+	switch path {
+	case "email":
+		email, err := s.validateEmail(user.ID, msg.GetEmail(), prefix+path)
+		if err != nil {
+			return true, err
+		}
+
+		if email != userEmail(user) {
+			// A new address has to be verified again.
+			updates["email_verified_at"] = nil
+		}
+		if email == "" {
+			updates["email"] = nil
+		} else {
+			updates["email"] = email
+		}
+		return true, nil
+	case "display_name":
+		name := strings.TrimSpace(msg.GetDisplayName())
+		if utf8.RuneCountInString(name) > maxDisplayNameLength {
+			return true, invalid("display name is too long")
+		}
+		if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			return true, invalid("display name contains control characters")
+		}
+
+		updates["display_name"] = name
+		return true, nil
+	case "avatar_url":
+		avatar := strings.TrimSpace(msg.GetAvatarUrl())
+		if avatar == "" {
+			updates["avatar_url"] = ""
+			return true, nil
+		}
+
+		u, err := url.Parse(avatar)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(avatar) > maxAvatarUrlLength {
+			return true, invalid("avatar url must be an http or https URL")
+		}
+
+		updates["avatar_url"] = avatar
+		return true, nil
+	case "locale":
+		if msg.GetLocale() == "" {
+			updates["locale"] = ""
+			return true, nil
+		}
+
+		tag, err := language.Parse(msg.GetLocale())
+		if err != nil {
+			return true, invalid("locale must be a BCP 47 language tag")
+		}
+
+		updates["locale"] = tag.String()
+		return true, nil
+	case "timezone":
+		if msg.GetTimezone() == "" {
+			updates["timezone"] = ""
+			return true, nil
+		}
+
+		if msg.GetTimezone() == "Local" {
+			return true, invalid("unknown time zone")
+		}
+		if _, err := time.LoadLocation(msg.GetTimezone()); err != nil {
+			return true, invalid("unknown time zone")
+		}
+
+		updates["timezone"] = msg.GetTimezone()
+		return true, nil
+	case "phone":
+		phone := strings.NewReplacer(" ", "", "-", "").Replace(msg.GetPhone())
+		if phone != "" && !phonePattern.MatchString(phone) {
+			return true, invalid("phone must be an E.164 number such as +14155550123")
+		}
+
+		updates["phone"] = phone
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// validateEmail checks that email is a plain address not used by another user
+// and returns it normalized. An empty email is valid. userID is the user the
+// address is for, 0 for new users.
+func (s *Server) validateEmail(userID uint, email string, fieldName string) (string, error) {
+	email, err := normalizeEmail(email, fieldName)
+	if err != nil || email == "" {
+		return "", err
+	}
+
+	taken, err := s.Users.EmailTaken(email, userID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", status.Error(codes.AlreadyExists, "email already in use")
+	}
+
+	return email, nil
+}
+
+// normalizeEmail checks that email is a plain address and returns it in lower
+// case. An empty email is valid.
+func normalizeEmail(email string, fieldName string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > maxEmailLength {
+		return "", invalidArgument(fieldViolation(fieldName, "invalid email address"))
+	}
+
+	return strings.ToLower(email), nil
+}
+
+func toPbProfile(user *models.User) *pb.Profile {
+	return &pb.Profile{
+		Id:            user.PublicId,
+		Name:          user.Name,
+		Email:         userEmail(user),
+		EmailVerified: user.EmailVerifiedAt != nil,
+		DisplayName:   user.DisplayName,
+		AvatarUrl:     user.AvatarUrl,
+		Locale:        user.Locale,
+		Timezone:      user.Timezone,
+		Phone:         user.Phone,
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+		UpdatedAt:     timestamppb.New(user.UpdatedAt),
+	}
+}
+
+func userEmail(user *models.User) string {
+	if user.Email == nil {
+		return ""
+	}
+
+	return *user.Email
+}
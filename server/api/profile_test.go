@@ -0,0 +1,180 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-auth/server/pb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+		valid bool
+	}{
+		{"", "", true},
+		{"  ", "", true},
+		{"alice@example.com", "alice@example.com", true},
+		{" Alice@Example.COM ", "alice@example.com", true},
+		{"alice", "", false},
+		{"alice@", "", false},
+		{"Alice <alice@example.com>", "", false},
+		{"alice@example.com, bob@example.com", "", false},
+		{strings.Repeat("a", 250) + "@example.com", "", false},
+	}
+
+	for _, tc := range tests {
+		got, err := normalizeEmail(tc.email, "email")
+		if (err == nil) != tc.valid {
+			t.Errorf("normalizeEmail(%q) error = %v, want valid %v", tc.email, err, tc.valid)
+			continue
+		}
+		if err != nil {
+			wantViolation(t, err, "email")
+		}
+		if got != tc.want {
+			t.Errorf("normalizeEmail(%q) = %q, want %q", tc.email, got, tc.want)
+		}
+	}
+}
+
+func TestUpdateMe(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	s.register(t, "bob")
+	ctx, _ := s.login(t, "alice", testPassword)
+	bobCtx, _ := s.login(t, "bob", testPassword)
+	if _, err := s.UpdateMe(bobCtx, updateMe("email", &pb.Profile{Email: "bob@example.com"})); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		req     *pb.UpdateMeRequest
+		want    codes.Code
+		wantBad string
+		check   func(p *pb.Profile) bool
+	}{
+		{"missing profile", &pb.UpdateMeRequest{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}}}, codes.InvalidArgument, "profile", nil},
+		{"missing mask", &pb.UpdateMeRequest{Profile: &pb.Profile{}}, codes.InvalidArgument, "update_mask", nil},
+		{"unsupported path", updateMe("name", &pb.Profile{Name: "mallory"}), codes.InvalidArgument, "update_mask", nil},
+		{"id is ignored", updateMe("id", &pb.Profile{Id: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetId() != "01ARZ3NDEKTSV4RRFFQ69G5FAV" }},
+
+		{"email", updateMe("email", &pb.Profile{Email: "Alice@Example.com"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetEmail() == "alice@example.com" }},
+		{"invalid email", updateMe("email", &pb.Profile{Email: "alice"}), codes.InvalidArgument, "profile.email", nil},
+		{"email of another user", updateMe("email", &pb.Profile{Email: "BOB@example.com"}), codes.AlreadyExists, "", nil},
+		{"clear email", updateMe("email", &pb.Profile{}), codes.OK, "", func(p *pb.Profile) bool { return p.GetEmail() == "" }},
+
+		{"display name", updateMe("display_name", &pb.Profile{DisplayName: "  Alice A.  "}), codes.OK, "", func(p *pb.Profile) bool { return p.GetDisplayName() == "Alice A." }},
+		{"display name too long", updateMe("display_name", &pb.Profile{DisplayName: strings.Repeat("é", 101)}), codes.InvalidArgument, "profile.display_name", nil},
+		{"trailing newline is trimmed", updateMe("display_name", &pb.Profile{DisplayName: "Alice\n"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetDisplayName() == "Alice" }},
+		{"display name with control characters", updateMe("display_name", &pb.Profile{DisplayName: "Ali\x00ce"}), codes.InvalidArgument, "profile.display_name", nil},
+
+		{"avatar url", updateMe("avatar_url", &pb.Profile{AvatarUrl: "https://example.com/a.png"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetAvatarUrl() == "https://example.com/a.png" }},
+		{"avatar url with other scheme", updateMe("avatar_url", &pb.Profile{AvatarUrl: "javascript:alert(1)"}), codes.InvalidArgument, "profile.avatar_url", nil},
+		{"avatar url without host", updateMe("avatar_url", &pb.Profile{AvatarUrl: "https:///a.png"}), codes.InvalidArgument, "profile.avatar_url", nil},
+		{"avatar url too long", updateMe("avatar_url", &pb.Profile{AvatarUrl: "https://example.com/" + strings.Repeat("a", 2048)}), codes.InvalidArgument, "profile.avatar_url", nil},
+
+		{"locale", updateMe("locale", &pb.Profile{Locale: "en-us"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetLocale() == "en-US" }},
+		{"invalid locale", updateMe("locale", &pb.Profile{Locale: "english please"}), codes.InvalidArgument, "profile.locale", nil},
+
+		{"timezone", updateMe("timezone", &pb.Profile{Timezone: "Europe/Berlin"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetTimezone() == "Europe/Berlin" }},
+		{"local timezone", updateMe("timezone", &pb.Profile{Timezone: "Local"}), codes.InvalidArgument, "profile.timezone", nil},
+		{"unknown timezone", updateMe("timezone", &pb.Profile{Timezone: "Mars/Olympus_Mons"}), codes.InvalidArgument, "profile.timezone", nil},
+
+		{"phone", updateMe("phone", &pb.Profile{Phone: "+1 415-555-0123"}), codes.OK, "", func(p *pb.Profile) bool { return p.GetPhone() == "+14155550123" }},
+		{"phone without country code", updateMe("phone", &pb.Profile{Phone: "4155550123"}), codes.InvalidArgument, "profile.phone", nil},
+
+		{"several fields", &pb.UpdateMeRequest{
+			Profile:    &pb.Profile{Locale: "de", Timezone: "UTC"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"locale", "timezone"}},
+		}, codes.OK, "", func(p *pb.Profile) bool { return p.GetLocale() == "de" && p.GetTimezone() == "UTC" }},
+		{"invalid field fails the whole update", &pb.UpdateMeRequest{
+			Profile:    &pb.Profile{Locale: "fr", Phone: "123"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"locale", "phone"}},
+		}, codes.InvalidArgument, "profile.phone", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := s.UpdateMe(ctx, tc.req)
+			err = toStatusError(err)
+			wantCode(t, err, tc.want)
+			if tc.wantBad != "" {
+				wantViolation(t, err, tc.wantBad)
+			}
+			if tc.check != nil && !tc.check(resp) {
+				t.Errorf("unexpected profile %v", resp)
+			}
+		})
+	}
+
+	profile, err := s.GetMe(ctx, &pb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.GetLocale() != "de" {
+		t.Errorf("failed update changed the locale to %q", profile.GetLocale())
+	}
+}
+
+// A new address has to be verified again, saving the same one keeps the
+// verification.
+func TestUpdateMeEmailVerification(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	ctx, _ := s.login(t, "alice", testPassword)
+	if _, err := s.UpdateMe(ctx, updateMe("email", &pb.Profile{Email: "alice@example.com"})); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		email        string
+		wantVerified bool
+	}{
+		{"alice@example.com", true},
+		{"ALICE@example.com", true},
+		{"alice@example.org", false},
+	}
+
+	for _, step := range steps {
+		if err := s.Users.Update(s.userID(t, "alice"), map[string]interface{}{"email_verified_at": time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := s.UpdateMe(ctx, updateMe("email", &pb.Profile{Email: step.email}))
+		if err != nil {
+			t.Fatalf("%s: %v", step.email, err)
+		}
+		if resp.GetEmailVerified() != step.wantVerified {
+			t.Errorf("%s: email verified = %v, want %v", step.email, resp.GetEmailVerified(), step.wantVerified)
+		}
+	}
+}
+
+func updateMe(path string, profile *pb.Profile) *pb.UpdateMeRequest {
+	return &pb.UpdateMeRequest{Profile: profile, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{path}}}
+}
+
+// wantViolation fails the test unless err reports a violation of field.
+func wantViolation(t *testing.T, err error, field string) {
+	t.Helper()
+
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				if violation.GetField() == field {
+					return
+				}
+			}
+		}
+	}
+	t.Errorf("%v does not report field %s", err, field)
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"go-auth/server/repository"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func (s *Server) CreateRole(ctx context.Context, req *pb.CreateRoleRequest) (*pb.Role, error) {
+	if err := requireFields(field{"name", req.GetName()}); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.findPermissions("permissions", req.GetPermissions())
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Permissions: permissions,
+	}
+
+	s.Logger.Info("Creating role: ", role.Name)
+	if err := s.Roles.Create(role); err != nil {
+		return nil, err
+	}
+
+	return toPbRole(role), nil
+}
+
+func (s *Server) ListRoles(ctx context.Context, req *pb.Empty) (*pb.ListRolesResponse, error) {
+	roles, err := s.Roles.List()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListRolesResponse{}
+	for i := range roles {
+		resp.Roles = append(resp.Roles, toPbRole(&roles[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) DeleteRole(ctx context.Context, req *pb.DeleteRoleRequest) (*pb.DefaultResponse, error) {
+	role, err := s.getRoleByName(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Name == models.AdminRole || role.Name == models.UserRole {
+		return nil, status.Error(codes.FailedPrecondition, "built-in roles cannot be deleted")
+	}
+
+	if err := s.Roles.Delete(role); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Deleted role: ", role.Name)
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+func (s *Server) CreatePermission(ctx context.Context, req *pb.CreatePermissionRequest) (*pb.Permission, error) {
+	if err := requireFields(field{"name", req.GetName()}); err != nil {
+		return nil, err
+	}
+
+	permission := &models.Permission{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	}
+
+	s.Logger.Info("Creating permission: ", permission.Name)
+	if err := s.Roles.CreatePermission(permission); err != nil {
+		return nil, err
+	}
+
+	return &pb.Permission{Name: permission.Name, Description: permission.Description}, nil
+}
+
+func (s *Server) ListPermissions(ctx context.Context, req *pb.Empty) (*pb.ListPermissionsResponse, error) {
+	permissions, err := s.Roles.ListPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListPermissionsResponse{}
+	for _, permission := range permissions {
+		resp.Permissions = append(resp.Permissions, &pb.Permission{
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GrantPermission(ctx context.Context, req *pb.RolePermissionRequest) (*pb.Role, error) {
+	role, err := s.getRoleByName(req.GetRole())
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.findPermissions("permission", []string{req.GetPermission()})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Roles.Grant(role, permissions); err != nil {
+		return nil, err
+	}
+
+	return s.reloadRole(role.Name)
+}
+
+func (s *Server) RevokePermission(ctx context.Context, req *pb.RolePermissionRequest) (*pb.Role, error) {
+	role, err := s.getRoleByName(req.GetRole())
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.findPermissions("permission", []string{req.GetPermission()})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Roles.Revoke(role, permissions); err != nil {
+		return nil, err
+	}
+
+	return s.reloadRole(role.Name)
+}
+
+func (s *Server) AssignRole(ctx context.Context, req *pb.UserRoleRequest) (*pb.DefaultResponse, error) {
+	user, role, err := s.getUserAndRole(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Users.AddRole(user, role); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Assigned role %s to user %d", role.Name, req.GetUserId())
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+func (s *Server) UnassignRole(ctx context.Context, req *pb.UserRoleRequest) (*pb.DefaultResponse, error) {
+	user, role, err := s.getUserAndRole(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Users.RemoveRole(user, role); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Unassigned role %s from user %d", role.Name, req.GetUserId())
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+// SeedRoles makes sure the built-in roles exist and grants the admin role to
+// the given users.
+func SeedRoles(transactor repository.Transactor, users repository.UserRepository, roles repository.RoleRepository, adminUsers []string) error {
+	return transactor.Transaction(func(tx *gorm.DB) error {
+		rbac := roles.WithTx(tx)
+
+		permissions := []models.Permission{
+			{Name: models.PermissionManageRoles, Description: "Manage roles, permissions and role assignments"},
+			{Name: models.PermissionManageUsers, Description: "Manage user accounts"},
+		}
+		for i := range permissions {
+			if err := rbac.FirstOrCreatePermission(&permissions[i]); err != nil {
+				return err
+			}
+		}
+
+		admin := models.Role{Name: models.AdminRole, Description: "Administrator"}
+		if err := rbac.FirstOrCreate(&admin); err != nil {
+			return err
+		}
+		if err := rbac.Grant(&admin, permissions); err != nil {
+			return err
+		}
+
+		user := models.Role{Name: models.UserRole, Description: "Default role of registered users"}
+		if err := rbac.FirstOrCreate(&user); err != nil {
+			return err
+		}
+
+		accounts := users.WithTx(tx)
+		for _, name := range adminUsers {
+			account, err := accounts.FindByName(name)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := accounts.AddRole(account, &admin); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Server) getRoleByName(name string) (*models.Role, error) {
+	if err := requireFields(field{"role", name}); err != nil {
+		return nil, err
+	}
+
+	role, err := s.Roles.FindByName(name)
+	if err != nil {
+		return nil, notFound(err, "role")
+	}
+
+	return role, nil
+}
+
+func (s *Server) reloadRole(name string) (*pb.Role, error) {
+	role, err := s.Roles.FindWithPermissions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPbRole(role), nil
+}
+
+func (s *Server) getUserAndRole(req *pb.UserRoleRequest) (*models.User, *models.Role, error) {
+	user, err := s.findUser(s.Users.FindByID, req.GetUserId())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	role, err := s.getRoleByName(req.GetRole())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, role, nil
+}
+
+// findPermissions loads the named permissions. field is the request field the
+// names come from and is reported when one of them does not exist.
+func (s *Server) findPermissions(field string, names []string) ([]models.Permission, error) {
+	unique := map[string]bool{}
+	for _, name := range names {
+		unique[name] = true
+	}
+	if len(unique) == 0 {
+		return nil, nil
+	}
+
+	permissions, err := s.Roles.FindPermissions(names)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(permissions) != len(unique) {
+		return nil, invalidArgument(fieldViolation(field, "unknown permission"))
+	}
+
+	return permissions, nil
+}
+
+func toPbRole(role *models.Role) *pb.Role {
+	resp := &pb.Role{
+		Name:        role.Name,
+		Description: role.Description,
+	}
+	for _, permission := range role.Permissions {
+		resp.Permissions = append(resp.Permissions, permission.Name)
+	}
+
+	return resp
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-auth/server/models"
+	"go-auth/server/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Every RPC has an access policy, the auth interceptor rejects the others.
+func TestMethodPolicies(t *testing.T) {
+	policies := MethodPolicies()
+
+	for _, desc := range []grpc.ServiceDesc{pb.UserService_ServiceDesc, pb.AdminService_ServiceDesc} {
+		var methods []string
+		for _, method := range desc.Methods {
+			methods = append(methods, method.MethodName)
+		}
+		for _, stream := range desc.Streams {
+			methods = append(methods, stream.StreamName)
+		}
+
+		for _, method := range methods {
+			fullMethod := "/" + desc.ServiceName + "/" + method
+			policy, ok := policies[fullMethod]
+			if !ok {
+				t.Errorf("%s has no policy", fullMethod)
+				continue
+			}
+			if strings.HasPrefix(desc.ServiceName, "pb.AdminService") && (policy.Public || len(policy.Permissions) == 0) {
+				t.Errorf("admin method %s does not require a permission", fullMethod)
+			}
+		}
+	}
+}
+
+// Handlers return plain errors, the error interceptor turns them into status
+// errors, so the steps compare the codes after toStatusError.
+func TestRoles(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	createRole := func(name string, permissions ...string) func() error {
+		return func() error {
+			_, err := s.CreateRole(ctx, &pb.CreateRoleRequest{Name: name, Permissions: permissions})
+			return err
+		}
+	}
+	grant := func(role, permission string) func() error {
+		return func() error {
+			_, err := s.GrantPermission(ctx, &pb.RolePermissionRequest{Role: role, Permission: permission})
+			return err
+		}
+	}
+	revoke := func(role, permission string) func() error {
+		return func() error {
+			_, err := s.RevokePermission(ctx, &pb.RolePermissionRequest{Role: role, Permission: permission})
+			return err
+		}
+	}
+	deleteRole := func(name string) func() error {
+		return func() error {
+			_, err := s.DeleteRole(ctx, &pb.DeleteRoleRequest{Name: name})
+			return err
+		}
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+		want codes.Code
+		// Permissions of the support role afterwards, nil when it must not
+		// exist.
+		wantPermissions []string
+	}{
+		{"missing name", createRole(""), codes.InvalidArgument, nil},
+		{"unknown permission", createRole("support", "users:read"), codes.InvalidArgument, nil},
+		{"create with a permission listed twice", createRole("support", models.PermissionManageUsers, models.PermissionManageUsers), codes.OK, []string{models.PermissionManageUsers}},
+		{"create again", createRole("support"), codes.AlreadyExists, []string{models.PermissionManageUsers}},
+		{"grant", grant("support", models.PermissionManageRoles), codes.OK, []string{models.PermissionManageRoles, models.PermissionManageUsers}},
+		{"grant twice", grant("support", models.PermissionManageRoles), codes.OK, []string{models.PermissionManageRoles, models.PermissionManageUsers}},
+		{"grant unknown permission", grant("support", "users:read"), codes.InvalidArgument, []string{models.PermissionManageRoles, models.PermissionManageUsers}},
+		{"grant to unknown role", grant("auditor", models.PermissionManageRoles), codes.NotFound, []string{models.PermissionManageRoles, models.PermissionManageUsers}},
+		{"revoke", revoke("support", models.PermissionManageRoles), codes.OK, []string{models.PermissionManageUsers}},
+		{"delete built-in role", deleteRole(models.AdminRole), codes.FailedPrecondition, []string{models.PermissionManageUsers}},
+		{"delete", deleteRole("support"), codes.OK, nil},
+		{"delete again", deleteRole("support"), codes.NotFound, nil},
+		{"create after delete", createRole("support"), codes.OK, []string{}},
+	}
+
+	for _, step := range steps {
+		if code := status.Code(toStatusError(step.run())); code != step.want {
+			t.Fatalf("%s: code %s, want %s", step.name, code, step.want)
+		}
+
+		resp, err := s.ListRoles(ctx, &pb.Empty{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var support *pb.Role
+		for _, role := range resp.GetRoles() {
+			if role.GetName() == "support" {
+				support = role
+			}
+		}
+		if (support != nil) != (step.wantPermissions != nil) {
+			t.Fatalf("%s: support role exists = %v", step.name, support != nil)
+		}
+		if support != nil && strings.Join(support.GetPermissions(), " ") != strings.Join(step.wantPermissions, " ") {
+			t.Errorf("%s: permissions %v, want %v", step.name, support.GetPermissions(), step.wantPermissions)
+		}
+	}
+}
+
+// Roles assigned to a user reach the tokens issued afterwards.
+func TestAssignRole(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+	ctx := context.Background()
+	if _, err := s.CreateRole(ctx, &pb.CreateRoleRequest{Name: "support", Permissions: []string{models.PermissionManageUsers}}); err != nil {
+		t.Fatal(err)
+	}
+	request := func(role string) *pb.UserRoleRequest {
+		return &pb.UserRoleRequest{UserId: uint64(s.userID(t, "alice")), Role: role}
+	}
+
+	steps := []struct {
+		name      string
+		run       func() error
+		want      codes.Code
+		wantRoles string
+	}{
+		{"assign", func() error { _, err := s.AssignRole(ctx, request("support")); return err }, codes.OK, "support user"},
+		{"assign twice", func() error { _, err := s.AssignRole(ctx, request("support")); return err }, codes.OK, "support user"},
+		{"assign unknown role", func() error { _, err := s.AssignRole(ctx, request("auditor")); return err }, codes.NotFound, "support user"},
+		{"assign to unknown user", func() error {
+			_, err := s.AssignRole(ctx, &pb.UserRoleRequest{UserId: 9999, Role: "support"})
+			return err
+		}, codes.NotFound, "support user"},
+		{"unassign", func() error { _, err := s.UnassignRole(ctx, request("support")); return err }, codes.OK, "user"},
+	}
+
+	for _, step := range steps {
+		if code := status.Code(toStatusError(step.run())); code != step.want {
+			t.Fatalf("%s: code %s, want %s", step.name, code, step.want)
+		}
+
+		_, resp := s.login(t, "alice", testPassword)
+		claims, err := s.Manager.Verify(resp.GetAccessToken())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if roles := strings.Join(claims.Roles, " "); roles != step.wantRoles {
+			t.Errorf("%s: token roles %q, want %q", step.name, roles, step.wantRoles)
+		}
+		if granted := claims.HasPermissions(models.PermissionManageUsers); granted != strings.Contains(step.wantRoles, "support") {
+			t.Errorf("%s: token grants %s = %v", step.name, models.PermissionManageUsers, granted)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	manager "go-auth/server/jwt"
+	"go-auth/server/pb"
+	"net/http"
+)
+
+func (s *Server) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginUserResponse, error) {
+	if err := requireFields(field{"refresh_token", req.GetRefreshToken()}); err != nil {
+		return nil, err
+	}
+
+	jwtToken, err := s.Manager.Refresh(req.GetRefreshToken())
+	if err != nil {
+		s.Logger.Warn("Failed to refresh token: ", err)
+		return nil, err
+	}
+
+	return loginResponse(jwtToken), nil
+}
+
+func (s *Server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.DefaultResponse, error) {
+	claims, ok := manager.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	if err := s.Manager.RevokeClaims(claims); err != nil {
+		return nil, err
+	}
+
+	if req.GetRefreshToken() != "" {
+		if err := s.Manager.Revoke(req.GetRefreshToken(), claims.UserId); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Logger.Info("User logged out: ", claims.UserId)
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+func (s *Server) RevokeToken(ctx context.Context, req *pb.RevokeTokenRequest) (*pb.DefaultResponse, error) {
+	claims, ok := manager.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+
+	if err := requireFields(field{"token", req.GetToken()}); err != nil {
+		return nil, err
+	}
+
+	if err := s.Manager.Revoke(req.GetToken(), claims.UserId); err != nil {
+		return nil, err
+	}
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+func loginResponse(jwtToken *manager.JWTToken) *pb.LoginUserResponse {
+	return &pb.LoginUserResponse{
+		Error:        false,
+		Code:         http.StatusOK,
+		Message:      "Success",
+		AccessToken:  jwtToken.GetAccessToken(),
+		RefreshToken: jwtToken.GetRefreshToken(),
+		ExpiresIn:    jwtToken.GetExpiresIn(),
+	}
+}
@@ -3,104 +3,345 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
+	"go-auth/server/lib/notifier"
+	"go-auth/server/lib/ulid"
 	"go-auth/server/models"
 	"go-auth/server/pb"
 	"net/http"
-	"strconv"
+	"unicode/utf8"
 
-	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"gorm.io/gorm"
 )
 
+const maxNameLength = 191
+
 func (s *Server) RegisterUser(ctx context.Context, req *pb.RegisterUserRequest) (*pb.DefaultResponse, error) {
 
-	s.Logger.Info("RegisterUser function was invoked with request: ", req)
+	s.Logger.Info("RegisterUser function was invoked for user: ", req.GetName())
+
+	if err := requireFields(
+		field{"name", req.GetName()},
+		field{"password", req.GetPassword()},
+	); err != nil {
+		return nil, err
+	}
 
-	existingUser, err := s.GetUserByName(ctx, req.Name)
-	if err == nil && existingUser != nil {
-		s.Logger.Warn("User with name already exists: ", req.Name)
-		return nil, errors.New("username already taken")
-	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := validateName(req.GetName(), "name"); err != nil {
 		return nil, err
 	}
 
-	hashedPassword, err := HashPassword(req.Password)
+	if s.Config.Get().RequireEmailVerification {
+		if err := requireFields(field{"email", req.GetEmail()}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkPasswordPolicy(req.GetPassword(), req.GetName(), "password"); err != nil {
+		return nil, err
+	}
+
+	var email string
+	var err error
+	if s.Config.Get().EnumerationSafeRegistration {
+		// Taken names and addresses get the same answer as new ones, the owner
+		// of the existing account is told about the attempt instead.
+		email, err = normalizeEmail(req.GetEmail(), "email")
+		if err != nil {
+			return nil, err
+		}
+
+		existingUser, err := s.Users.FindTaken(req.GetName(), email)
+		if err != nil {
+			return nil, err
+		}
+		if existingUser != nil {
+			if existingUser.DeletedAt.Valid {
+				// Nobody is told, but the request still takes as long as a
+				// real registration.
+				if _, err := s.HashPassword(ctx, req.GetPassword()); err != nil {
+					return nil, err
+				}
+				return registrationResponse(), nil
+			}
+			if err := s.notifyRegistrationAttempt(ctx, existingUser, req.GetPassword()); err != nil {
+				return nil, err
+			}
+			return registrationResponse(), nil
+		}
+	} else {
+		email, err = s.validateEmail(0, req.GetEmail(), "email")
+		if err != nil {
+			return nil, err
+		}
+
+		existingUser, err := s.Users.FindTaken(req.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		if existingUser != nil {
+			s.Logger.Warn("User with name already exists: ", req.Name)
+			return nil, status.Error(codes.AlreadyExists, "username already taken")
+		}
+	}
+
+	hashedPassword, err := s.HashPassword(ctx, req.Password)
+	if err != nil {
+		return nil, err
+	}
 
 	user := &models.User{
 		Name:     req.Name,
 		Password: hashedPassword,
+		Status:   models.UserStatusActive,
 	}
+	if email != "" {
+		user.Email = &email
+		user.Status = models.UserStatusPendingVerification
+	}
+
+	s.Logger.Info("Creating user: ", user.Name)
+	err = s.Transactor.Transaction(func(tx *gorm.DB) error {
+		users := s.Users.WithTx(tx)
+		if err := users.Create(user); err != nil {
+			return err
+		}
+
+		role, err := s.Roles.WithTx(tx).FindByName(models.UserRole)
+		if err != nil {
+			return err
+		}
 
-	s.Logger.Info("Creating user to db: ", user)
-	if err := s.Db.Create(user).Error; err != nil {
+		return users.AddRole(user, role)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) && s.Config.Get().EnumerationSafeRegistration {
+		// Lost a race with a concurrent registration of the same name or email.
+		s.Logger.Warn("Concurrent registration of user: ", req.Name)
+		return registrationResponse(), nil
+	}
+	if err != nil {
 		return nil, err
 	}
 
+	if user.Email != nil {
+		// The account exists at this point, a failure does not fail the
+		// registration. The user can ask for another message with
+		// SendVerification.
+		if err := s.sendVerification(ctx, user); err != nil {
+			s.Logger.Error("Failed to issue verification token: ", err)
+		}
+	}
+
+	return registrationResponse(), nil
+}
+
+func registrationResponse() *pb.DefaultResponse {
 	return &pb.DefaultResponse{
 		Error:   false,
 		Code:    http.StatusOK,
 		Message: "Success",
-	}, nil
+	}
+}
+
+// notifyRegistrationAttempt tells the owner of user that someone tried to
+// register with their name or email address. The password is hashed and thrown
+// away so the request takes as long as a real registration. Failed deliveries
+// are only logged, like the verification message of a real registration.
+func (s *Server) notifyRegistrationAttempt(ctx context.Context, user *models.User, password string) error {
+	if _, err := s.HashPassword(ctx, password); err != nil {
+		return err
+	}
+
+	err := s.Notifier.Notify(ctx, notifier.Message{
+		To:      recipient(user),
+		Subject: "Registration attempt with your account details",
+		Body: "Someone tried to register a new account with your user name or email address. " +
+			"If this was you, log in to your existing account or reset your password. " +
+			"Otherwise you can ignore this message.",
+		Data: map[string]string{
+			"name": user.Name,
+		},
+	})
+	if err != nil {
+		s.Logger.Error("Failed to send registration attempt message: ", err)
+		return nil
+	}
+
+	s.Logger.Info("Registration attempt reported to user: ", user.ID)
+
+	return nil
 }
 
 func (s *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if err := requireFields(
+		field{"name", req.GetName()},
+		field{"password", req.GetPassword()},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkClientThrottle(ctx); err != nil {
+		return nil, err
+	}
+
+	// Unknown names, wrong passwords and locked accounts all cost one password
+	// check and share their answers, so they cannot be told apart.
 	user, err := s.GetUserByName(ctx, req.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.verifyDummyPassword(ctx, req.Password); err != nil {
+			return nil, err
+		}
+		if err := s.checkUnknownNameLock(req.Name); err != nil {
+			return nil, err
+		}
+		s.recordClientFailure(ctx)
+		s.recordUnknownNameFailure(req.Name)
+		return nil, errInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	passwordOk, needsRehash, err := s.VerifyPassword(ctx, req.Password, user.Password)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkAccountLock(user); err != nil {
+		return nil, err
+	}
+	if !passwordOk {
+		s.recordClientFailure(ctx)
+		if err := s.recordAccountFailure(user); err != nil {
+			return nil, err
+		}
+		return nil, errInvalidCredentials
+	}
 
-	if req.GetName() == "" {
-		return nil, errors.New("name is required")
+	if err := s.checkUserActive(user); err != nil {
+		return nil, err
 	}
 
-	if req.GetPassword() == "" {
-		return nil, errors.New("password is required")
+	if needsRehash {
+		s.rehashPassword(ctx, user, req.Password)
 	}
 
-	if !VerifyPassword(req.Password, user.Password) {
-		return nil, errors.New("invalid credentials")
+	if err := s.resetAccountFailures(user); err != nil {
+		return nil, err
 	}
 
-	jwtToken, err := s.Manager.Generate(
-		strconv.FormatUint(uint64(user.Id), 10),
-	)
+	if user.MfaEnabled {
+		mfaToken, err := s.Manager.GenerateMfaChallenge(user.PublicId)
+		if err != nil {
+			return nil, err
+		}
 
-	return &pb.LoginUserResponse{
-		Error:       false,
-		Code:        http.StatusOK,
-		Message:     "Success",
-		AccessToken: jwtToken.GetAccessToken(),
-	}, nil
-}
+		return &pb.LoginUserResponse{
+			Error:       false,
+			Code:        http.StatusOK,
+			Message:     "MFA required",
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+		}, nil
+	}
 
-func (s *Server) GetUserByName(ctx context.Context, name string) (*models.User, error) {
-	user := &models.User{}
-	if err := s.Db.Where("name = ?", name).First(user).Error; err != nil {
+	jwtToken, err := s.Manager.Generate(user.PublicId)
+	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	return loginResponse(jwtToken), nil
+}
+
+func (s *Server) GetUserByName(ctx context.Context, name string) (*models.User, error) {
+	return s.Users.FindByName(name)
 }
 
 func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
-	
-	var user models.User
-	
-	err := s.Db.Where("id = ?", req.Id).First(&user).Error; 
+	if !ulid.Valid(req.GetId()) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	user, err := s.Users.FindByPublicID(req.GetId())
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "user")
+	}
+
+	return &pb.GetUserResponse{Id: user.PublicId, Name: user.Name}, nil
+}
+
+// validateName rejects user names that do not fit the name column.
+func validateName(name, fieldName string) error {
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return invalidArgument(fieldViolation(fieldName, fmt.Sprintf("name must not be longer than %d characters", maxNameLength)))
 	}
 
-	return &pb.GetUserResponse{Id: req.Id, Name: user.Name}, nil
+	return nil
 }
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+// HashPassword hashes password with the configured default algorithm on the
+// hashing pool.
+func (s *Server) HashPassword(ctx context.Context, password string) (string, error) {
+	var hash string
+	var err error
+	if poolErr := s.HashPool.Do(ctx, func() {
+		hash, err = s.Passwords.Hash(password)
+	}); poolErr != nil {
+		return "", poolErr
+	}
+
+	return hash, err
 }
 
-func VerifyPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// VerifyPassword checks password against a stored hash on the hashing pool.
+// needsRehash is true when the password matches but the hash uses an outdated
+// algorithm or parameters. err is only set when the check could not run.
+func (s *Server) VerifyPassword(ctx context.Context, password, hash string) (ok bool, needsRehash bool, err error) {
+	var verifyErr error
+	if err := s.HashPool.Do(ctx, func() {
+		ok, needsRehash, verifyErr = s.Passwords.Verify(password, hash)
+	}); err != nil {
+		return false, false, err
+	}
+
+	if verifyErr != nil {
+		s.Logger.Warn("Failed to verify password hash: ", verifyErr)
+		return false, false, nil
+	}
+
+	return ok, needsRehash, nil
+}
+
+// verifyDummyPassword checks password against a hash no password matches.
+func (s *Server) verifyDummyPassword(ctx context.Context, password string) error {
+	hash, err := s.Passwords.DummyHash()
+	if err != nil {
+		return err
+	}
+
+	_, _, err = s.VerifyPassword(ctx, password, hash)
+	return err
+}
+
+// rehashPassword replaces an outdated hash after a successful login. The
+// update is skipped when the password was changed concurrently.
+func (s *Server) rehashPassword(ctx context.Context, user *models.User, password string) {
+	hash, err := s.HashPassword(ctx, password)
+	if err != nil {
+		s.Logger.Error("Failed to rehash password: ", err)
+		return
+	}
+
+	swapped, err := s.Users.SwapPassword(user.ID, user.Password, hash)
+	if err != nil {
+		s.Logger.Error("Failed to store rehashed password: ", err)
+		return
+	}
+	if !swapped {
+		return
+	}
+
+	s.Logger.Info("Rehashed password of user: ", user.ID)
 }
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-auth/server/config"
+	"go-auth/server/pb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginUser(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "alice")
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		want     codes.Code
+	}{
+		{"missing password", "alice", "", codes.InvalidArgument},
+		{"unknown name", "bob", testPassword, codes.Unauthenticated},
+		{"wrong password", "alice", "Wrong-Horse-77", codes.Unauthenticated},
+		{"success", "alice", testPassword, codes.OK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.LoginUser(context.Background(), &pb.LoginUserRequest{Name: tc.user, Password: tc.password})
+			wantCode(t, err, tc.want)
+		})
+	}
+}
+
+// Unknown names have to fail exactly like wrong passwords of an existing
+// account: same answers, same back-off and lockout, one hash per attempt.
+func TestLoginUserHidesAccounts(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 1
+		c.LoginMaxAttempts = 3
+		c.LoginMaxAttemptsPerIp = 100
+		c.LoginLockoutDuration = time.Hour
+	})
+	s.register(t, "alice")
+
+	attempt := func(name string) (string, int64) {
+		before := s.hashesDone()
+		_, err := s.LoginUser(context.Background(), &pb.LoginUserRequest{Name: name, Password: "Wrong-Horse-77"})
+		// Let the back-off of the failure pass.
+		time.Sleep(5 * time.Millisecond)
+		return answer(err), s.hashesDone() - before
+	}
+
+	for i := 1; i <= 5; i++ {
+		existing, existingHashes := attempt("alice")
+		unknown, unknownHashes := attempt("bob")
+
+		if existing != unknown {
+			t.Errorf("attempt %d: existing name got %s, unknown name got %s", i, existing, unknown)
+		}
+		if existingHashes != 1 || unknownHashes != 1 {
+			t.Errorf("attempt %d: %d hashes for existing name, %d for unknown name, want 1", i, existingHashes, unknownHashes)
+		}
+		if want := codes.Unauthenticated.String(); i <= 3 && existing != want {
+			t.Errorf("attempt %d: got %s, want %s", i, existing, want)
+		}
+		if want := codes.ResourceExhausted.String() + " " + ReasonAccountLocked; i > 3 && existing != want {
+			t.Errorf("attempt %d: got %s, want %s", i, existing, want)
+		}
+	}
+}
+
+// answer describes err by its code and error reason, leaving out the time to
+// wait which differs by a second now and then.
+func answer(err error) string {
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return st.Code().String() + " " + info.GetReason()
+		}
+	}
+
+	return st.Code().String()
+}
+
+// With enumeration safe registration taken names, including the ones of
+// deleted accounts, get the answer and cost of a new registration.
+func TestRegisterUserEnumerationSafe(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.EnumerationSafeRegistration = true
+	})
+	s.register(t, "alice")
+	s.register(t, "deleted")
+	deleted, err := s.Users.FindByName("deleted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users.SoftDelete(deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		user         string
+		wantNotified bool
+	}{
+		{"new name", "bob", false},
+		{"taken name", "alice", true},
+		{"name of deleted account", "deleted", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before, sent := s.hashesDone(), s.notifier.count()
+
+			resp, err := s.RegisterUser(context.Background(), &pb.RegisterUserRequest{Name: tc.user, Password: testPassword})
+			wantCode(t, err, codes.OK)
+			if resp.GetMessage() != "Success" {
+				t.Errorf("message = %q", resp.GetMessage())
+			}
+			if done := s.hashesDone() - before; done != 1 {
+				t.Errorf("%d passwords hashed, want 1", done)
+			}
+			if notified := s.notifier.count() > sent; notified != tc.wantNotified {
+				t.Errorf("owner notified = %v, want %v", notified, tc.wantNotified)
+			}
+		})
+	}
+}
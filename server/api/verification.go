@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-auth/server/lib/actiontoken"
+	"go-auth/server/lib/notifier"
+	"go-auth/server/models"
+	"go-auth/server/pb"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	// Purpose of the action tokens sent by SendVerification. The subject is
+	// "<internal user id>:<email>" so a token stops working when the address changes.
+	actionEmailVerification = "email_verification"
+
+	ReasonEmailNotVerified = "EMAIL_NOT_VERIFIED"
+)
+
+var (
+	errInvalidVerificationToken = invalidArgument(fieldViolation("token", "invalid or expired verification token"))
+	errEmailNotVerified         = emailNotVerifiedError()
+)
+
+// SendVerification sends a new verification token to the email address of the
+// user and invalidates earlier ones. The response is the same whether or not
+// the account exists or needs verification.
+func (s *Server) SendVerification(ctx context.Context, req *pb.SendVerificationRequest) (*pb.DefaultResponse, error) {
+	if err := requireFields(field{"name", req.GetName()}); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMessageThrottle(ctx, req.GetName()); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "If the account has an unverified email address a verification message has been sent",
+	}
+
+	user, err := s.GetUserByName(ctx, req.GetName())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email == nil || user.EmailVerifiedAt != nil || user.Status == models.UserStatusDisabled {
+		return resp, nil
+	}
+
+	if err := s.sendVerification(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// VerifyEmail marks the email address a token was sent to as verified and
+// activates accounts pending verification.
+func (s *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.DefaultResponse, error) {
+	if err := requireFields(field{"token", req.GetToken()}); err != nil {
+		return nil, err
+	}
+
+	subject, err := s.ActionTokens.Consume(actionEmailVerification, req.GetToken())
+	if errors.Is(err, actiontoken.ErrInvalidToken) {
+		return nil, errInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	userID, email, _ := strings.Cut(subject, ":")
+	id, err := strconv.ParseUint(userID, 10, strconv.IntSize)
+	if err != nil {
+		return nil, errInvalidVerificationToken
+	}
+
+	user, err := s.Users.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if userEmail(user) != email {
+		return nil, errInvalidVerificationToken
+	}
+
+	updates := map[string]interface{}{"email_verified_at": time.Now()}
+	if user.Status == models.UserStatusPendingVerification {
+		updates["status"] = models.UserStatusActive
+	}
+	if err := s.Users.Update(user.ID, updates); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Email verified for user: ", user.ID)
+
+	return &pb.DefaultResponse{
+		Error:   false,
+		Code:    http.StatusOK,
+		Message: "Success",
+	}, nil
+}
+
+// sendVerification issues a verification token for the email address of user
+// and sends it there. A failed delivery is only logged, an error would tell
+// callers of SendVerification that the account has an unverified address.
+func (s *Server) sendVerification(ctx context.Context, user *models.User) error {
+	email := userEmail(user)
+
+	// Tokens sent to earlier addresses are spent as well.
+	if err := s.ActionTokens.RevokeAll(actionEmailVerification, user.ID); err != nil {
+		return err
+	}
+
+	token, err := s.ActionTokens.Issue(
+		actionEmailVerification,
+		user.ID,
+		strconv.FormatUint(uint64(user.ID), 10)+":"+email,
+		s.Config.Get().EmailVerificationTokenDuration,
+	)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Use this token to verify your email address: %s", token)
+	if s.Config.Get().EmailVerificationUrl != "" {
+		body = fmt.Sprintf("Open this link to verify your email address: %s",
+			strings.Replace(s.Config.Get().EmailVerificationUrl, "%s", token, 1))
+	}
+
+	err = s.Notifier.Notify(ctx, notifier.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body:    body,
+		Data: map[string]string{
+			"token":      token,
+			"expires_in": s.Config.Get().EmailVerificationTokenDuration.String(),
+		},
+	})
+	if err != nil {
+		s.Logger.Error("Failed to send verification message: ", err)
+		return nil
+	}
+
+	s.Logger.Info("Verification message sent to user: ", user.ID)
+
+	return nil
+}
+
+// checkUserActive rejects users that may not log in.
+func (s *Server) checkUserActive(user *models.User) error {
+	switch user.Status {
+	case models.UserStatusDisabled:
+		return errAccountDisabled
+	case models.UserStatusPendingVerification:
+		if s.Config.Get().RequireEmailVerification {
+			return errEmailNotVerified
+		}
+	}
+
+	return nil
+}
+
+// recipient is the address notifications for user are sent to.
+func recipient(user *models.User) string {
+	if user.Email != nil {
+		return *user.Email
+	}
+
+	return user.Name
+}
+
+func emailNotVerifiedError() error {
+	st := status.New(codes.FailedPrecondition, "email address is not verified")
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: ReasonEmailNotVerified, Domain: "go-auth"})
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
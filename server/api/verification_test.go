@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-auth/server/config"
+	"go-auth/server/pb"
+
+	"google.golang.org/grpc/codes"
+)
+
+// The response must not tell whether the account exists or has an unverified
+// address, even when the message cannot be sent.
+func TestSendVerificationHidesAccounts(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 10
+		c.LoginMaxAttempts = 20
+		c.LoginMaxAttemptsPerIp = 100
+	})
+	s.register(t, "plain")
+	for _, name := range []string{"unverified", "verified"} {
+		_, err := s.RegisterUser(context.Background(), &pb.RegisterUserRequest{Name: name, Password: testPassword, Email: name + "@example.com"})
+		wantCode(t, err, codes.OK)
+	}
+	if err := s.Users.Update(s.userID(t, "verified"), map[string]interface{}{"email_verified_at": time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		user        string
+		notifierErr error
+		wantSent    bool
+	}{
+		{"unverified email", "unverified", nil, true},
+		{"unknown user", "nobody", nil, false},
+		{"no email", "plain", nil, false},
+		{"verified email", "verified", nil, false},
+		{"unverified email, notifier down", "unverified", errors.New("notifier down"), false},
+		{"unknown user, notifier down", "nobody", errors.New("notifier down"), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s.notifier.err = tc.notifierErr
+			sent := s.notifier.count()
+
+			resp, err := s.SendVerification(context.Background(), &pb.SendVerificationRequest{Name: tc.user})
+			wantCode(t, err, codes.OK)
+			if resp.GetMessage() != "If the account has an unverified email address a verification message has been sent" {
+				t.Errorf("message = %q", resp.GetMessage())
+			}
+			if got := s.notifier.count() > sent; got != tc.wantSent {
+				t.Errorf("message sent = %v, want %v", got, tc.wantSent)
+			}
+		})
+	}
+}
+
+// Verification messages are throttled per name and per client, unknown names
+// like existing ones.
+func TestSendVerificationThrottle(t *testing.T) {
+	s := newTestServer(t, func(c *config.Config) {
+		c.LoginFreeAttempts = 0
+		c.LoginMaxAttempts = 2
+		c.LoginMaxAttemptsPerIp = 3
+		c.LoginLockoutDuration = time.Hour
+	})
+	_, err := s.RegisterUser(context.Background(), &pb.RegisterUserRequest{Name: "alice", Password: testPassword, Email: "alice@example.com"})
+	wantCode(t, err, codes.OK)
+	attacker := fromIP("203.0.113.7")
+	other := fromIP("198.51.100.1")
+	throttled := codes.ResourceExhausted.String() + " " + ReasonTooManyAttempts
+
+	steps := []struct {
+		name string
+		ctx  context.Context
+		user string
+		want string
+	}{
+		{"first message", attacker, "alice", codes.OK.String()},
+		{"second message", attacker, "alice", codes.OK.String()},
+		{"name throttled", attacker, "alice", throttled},
+		{"name throttled for other clients", other, "alice", throttled},
+		{"unknown name", attacker, "nobody", codes.OK.String()},
+		{"client throttled", attacker, "carol", throttled},
+		{"other client", other, "carol", codes.OK.String()},
+	}
+
+	for _, step := range steps {
+		_, err := s.SendVerification(step.ctx, &pb.SendVerificationRequest{Name: step.user})
+		time.Sleep(5 * time.Millisecond)
+		if got := answer(err); got != step.want {
+			t.Fatalf("%s: got %s, want %s", step.name, got, step.want)
+		}
+	}
+}
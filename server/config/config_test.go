@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListenAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		listen   []string
+		wantGrpc string
+		wantHttp string
+	}{
+		{"all interfaces", nil, ":50051", ":8080"},
+		{"one address", []string{"127.0.0.1"}, "127.0.0.1:50051", "127.0.0.1:8080"},
+		{"several addresses", []string{"192.168.1.12", "localhost"}, "192.168.1.12:50051 localhost:50051", "192.168.1.12:8080 localhost:8080"},
+		{"ipv6", []string{"::1"}, "[::1]:50051", "[::1]:8080"},
+		{"bracketed ipv6", []string{"[::1]"}, "[::1]:50051", "[::1]:8080"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Default()
+			c.ListenAddress = tc.listen
+
+			if got := strings.Join(c.GrpcAddresses(), " "); got != tc.wantGrpc {
+				t.Errorf("GrpcAddresses = %s, want %s", got, tc.wantGrpc)
+			}
+			if got := strings.Join(c.HttpAddresses(), " "); got != tc.wantHttp {
+				t.Errorf("HttpAddresses = %s, want %s", got, tc.wantHttp)
+			}
+		})
+	}
+}
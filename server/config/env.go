@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Sources of settings, see Config.Source.
+const (
+	sourceDefault = "default"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// loadEnv sets every field whose config tag names a set environment variable.
+func (c *Config) loadEnv() error {
+	var errs []error
+	c.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		if raw, ok := os.LookupEnv(key); ok {
+			errs = append(errs, c.set(key, value, raw, sourceEnv))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// set parses raw into the field value with the given key and records where it
+// came from.
+func (c *Config) set(key string, value reflect.Value, raw string, source string) error {
+	if err := setField(value, raw); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	if c.sources == nil {
+		c.sources = map[string]string{}
+	}
+	c.sources[key] = source
+
+	return nil
+}
+
+// Source returns where the setting with the given key comes from: "default",
+// "env", "flag" or "file" followed by the path of the config file.
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+
+	return sourceDefault
+}
+
+// File returns the path of the config file the settings were read from,
+// empty when there is none.
+func (c *Config) File() string {
+	return c.file
+}
+
+// fields calls fn for every field of c with a config tag.
+func (c *Config) fields(fn func(key string, field reflect.StructField, value reflect.Value)) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("config")
+		if key == "" {
+			continue
+		}
+
+		fn(key, t.Field(i), v.Field(i))
+	}
+}
+
+// setField parses raw into value according to its type. Lists are comma
+// separated, empty entries are dropped.
+func setField(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", value.Type())
+		}
+		value.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+func splitList(raw string) []string {
+	var list []string
+	for _, entry := range strings.Split(raw, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
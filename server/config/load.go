@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Names the config file when the -config flag is not given.
+const configFileKey = "CONFIG_FILE"
+
+// Options tell Load where to read the settings from.
+type Options struct {
+	// Dotenv file whose variables are added to the environment, .env when
+	// empty. Variables already set keep their value.
+	EnvFile string
+	// Command line arguments without the program name. Every setting has a
+	// flag named after its key in lower case with dashes, e.g. -grpc-port for
+	// GRPC_PORT, except the secret ones: the command line shows up in ps and
+	// shell history. -config names a YAML or TOML config file.
+	Args []string
+}
+
+// Load returns the settings of the service. Each source overrides the ones
+// before: the defaults, the config file, the environment and the command
+// line. The config file uses the keys in lower case, e.g. grpc_port. The
+// command line arguments after the flags are returned.
+//
+// The settings are not validated, see Validate.
+func Load(opts Options) (*Config, []string, error) {
+	envFile := opts.EnvFile
+	if envFile == "" {
+		envFile = ".env"
+	}
+	// The file is optional.
+	godotenv.Load(envFile)
+
+	c := Default()
+
+	flags, err := c.parseFlags(opts.Args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.file == "" {
+		c.file = os.Getenv(configFileKey)
+	}
+	if c.file != "" {
+		if err := c.loadFile(c.file); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := c.loadEnv(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := flags.apply(c); err != nil {
+		return nil, nil, err
+	}
+
+	return c, flags.rest, nil
+}
+
+// parsedFlags are the settings given on the command line, applied after the
+// other sources.
+type parsedFlags struct {
+	values map[string]string
+	rest   []string
+}
+
+// parseFlags parses args and sets c.file from the -config flag.
+func (c *Config) parseFlags(args []string) (*parsedFlags, error) {
+	parsed := &parsedFlags{values: map[string]string{}}
+
+	fs := flag.NewFlagSet("go-auth", flag.ContinueOnError)
+	fs.StringVar(&c.file, "config", "", "YAML or TOML config file, overrides "+configFileKey)
+
+	c.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" {
+			return
+		}
+
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		usage := "sets " + key
+		store := func(raw string) error {
+			parsed.values[key] = raw
+			return nil
+		}
+
+		if value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, usage, store)
+		} else {
+			fs.Func(name, usage, store)
+		}
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	parsed.rest = fs.Args()
+
+	return parsed, nil
+}
+
+func (f *parsedFlags) apply(c *Config) error {
+	var errs []error
+	c.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		if raw, ok := f.values[key]; ok {
+			errs = append(errs, c.set(key, value, raw, sourceFlag))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// loadFile sets the fields found in the YAML or TOML file at path.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("%s: config files must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	source := "file " + path
+	fields := map[string]reflect.Value{}
+	c.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		fields[strings.ToLower(key)] = value
+	})
+
+	// Sorted so errors are reported in a stable order.
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		value, ok := fields[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %s", path, name))
+			continue
+		}
+
+		key := strings.ToUpper(name)
+		switch v := values[name].(type) {
+		case nil:
+			errs = append(errs, c.set(key, value, "", source))
+		case []interface{}:
+			if value.Kind() != reflect.Slice {
+				errs = append(errs, fmt.Errorf("%s: %s must not be a list", path, name))
+				continue
+			}
+
+			list := make([]string, 0, len(v))
+			for _, entry := range v {
+				list = append(list, fmt.Sprint(entry))
+			}
+			errs = append(errs, c.set(key, value, strings.Join(list, ","), source))
+		case map[string]interface{}:
+			errs = append(errs, fmt.Errorf("%s: %s must not be a table", path, name))
+		default:
+			errs = append(errs, c.set(key, value, fmt.Sprint(v), source))
+		}
+	}
+
+	return errors.Join(errs...)
+}
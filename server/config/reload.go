@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// Editors write files in several steps, a reload waits for them to settle.
+const reloadDelay = 200 * time.Millisecond
+
+// Live holds the configuration in use. A reload replaces the Config as a
+// whole, so a Config returned by Get never changes and can be read without
+// locking.
+type Live struct {
+	current atomic.Pointer[Config]
+}
+
+func NewLive(c *Config) *Live {
+	live := &Live{}
+	live.current.Store(c)
+	return live
+}
+
+func (l *Live) Get() *Config {
+	return l.current.Load()
+}
+
+// Reloader reads the settings again on SIGHUP and when the config file
+// changes. Only the settings tagged reload take effect, changes to the others
+// are logged and wait for a restart.
+type Reloader struct {
+	live   *Live
+	opts   Options
+	logger *logrus.Logger
+
+	mu    sync.Mutex
+	hooks []func(c *Config)
+}
+
+// NewReloader returns a Reloader updating live from the sources described by
+// opts, which should be the options live was loaded with.
+func NewReloader(live *Live, opts Options, logger *logrus.Logger) *Reloader {
+	return &Reloader{live: live, opts: opts, logger: logger}
+}
+
+// OnReload registers fn to be called with the new configuration after a
+// reload changed it, e.g. to pass settings to components keeping a copy.
+func (r *Reloader) OnReload(fn func(c *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks = append(r.hooks, fn)
+}
+
+// Reload reads the settings and applies the changed reloadable ones. When the
+// settings are invalid the configuration stays as it is.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fresh, _, err := Load(r.opts)
+	if err != nil {
+		return err
+	}
+	// Settings a restart would reject are not applied either.
+	if err := fresh.Validate(); err != nil {
+		return err
+	}
+
+	current := r.live.Get()
+	next := *current
+	next.sources = map[string]string{}
+	for key, source := range current.sources {
+		next.sources[key] = source
+	}
+
+	var changed, restart []string
+	currentValue := reflect.ValueOf(current).Elem()
+	freshValue := reflect.ValueOf(fresh).Elem()
+	nextValue := reflect.ValueOf(&next).Elem()
+	current.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		i := field.Index[0]
+		if reflect.DeepEqual(currentValue.Field(i).Interface(), freshValue.Field(i).Interface()) {
+			return
+		}
+
+		if field.Tag.Get("reload") != "true" {
+			restart = append(restart, key)
+			return
+		}
+
+		nextValue.Field(i).Set(freshValue.Field(i))
+		next.sources[key] = fresh.Source(key)
+		changed = append(changed, key)
+	})
+
+	if len(restart) > 0 {
+		r.logger.Warnf("Changed settings %s take effect after a restart", strings.Join(restart, ", "))
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	// Reloaded settings may conflict with ones kept until the restart.
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	r.live.current.Store(&next)
+	r.logger.Infof("Reloaded settings %s", strings.Join(changed, ", "))
+
+	for _, hook := range r.hooks {
+		hook(&next)
+	}
+
+	return nil
+}
+
+// Run reloads on SIGHUP and on changes of the config file until ctx is done.
+// Failed reloads are logged.
+func (r *Reloader) Run(ctx context.Context) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var events chan fsnotify.Event
+	var watchErrors chan error
+	file := r.live.Get().File()
+	if file != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+
+		// The directory is watched as editors and config management often
+		// replace the file instead of writing it.
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			return err
+		}
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			r.reload("SIGHUP")
+		case event := <-events:
+			if filepath.Clean(event.Name) == filepath.Clean(file) && !event.Has(fsnotify.Chmod) {
+				settle = time.After(reloadDelay)
+			}
+		case <-settle:
+			settle = nil
+			r.reload("change of " + file)
+		case err := <-watchErrors:
+			r.logger.Error("Failed to watch config file: ", err)
+		}
+	}
+}
+
+func (r *Reloader) reload(reason string) {
+	r.logger.Info("Reloading configuration after ", reason)
+	if err := r.Reload(); err != nil {
+		r.logger.Error("Failed to reload configuration: ", err)
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestReloader loads the settings from a YAML file with content and returns
+// a Reloader of them together with the path of the file.
+func newTestReloader(t *testing.T, content string) (*Reloader, *Live, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", content)
+	opts := Options{EnvFile: filepath.Join(dir, "missing.env"), Args: []string{"-config", path}}
+
+	c, _, err := Load(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	live := NewLive(c)
+
+	return NewReloader(live, opts, logger), live, path
+}
+
+// Only reloadable settings change while the service runs. Invalid settings
+// leave the configuration as it is.
+func TestReload(t *testing.T) {
+	base := "app_key: " + testAppKey + "\n"
+	reloader, live, path := newTestReloader(t, base+"log_level: info\ngrpc_port: 6000\n")
+
+	var hooked []*Config
+	reloader.OnReload(func(c *Config) { hooked = append(hooked, c) })
+
+	steps := []struct {
+		name      string
+		file      string
+		wantErr   bool
+		wantLevel string
+		wantPort  int
+		wantHook  bool
+	}{
+		{"unchanged", "log_level: info\ngrpc_port: 6000\n", false, "info", 6000, false},
+		{"reloadable setting", "log_level: debug\ngrpc_port: 6000\n", false, "debug", 6000, true},
+		{"setting needing a restart", "log_level: debug\ngrpc_port: 7000\n", false, "debug", 6000, false},
+		{"both", "log_level: warn\ngrpc_port: 7000\n", false, "warn", 6000, true},
+		{"invalid value", "log_level: loud\ngrpc_port: 6000\n", true, "warn", 6000, false},
+		{"unparsable file", "log_level: [\n", true, "warn", 6000, false},
+		{"invalid combination", "log_level: warn\ngrpc_port: 6000\nlogin_backoff_base: 2h\n", true, "warn", 6000, false},
+	}
+
+	for _, step := range steps {
+		before := live.Get()
+		hooks := len(hooked)
+		writeFile(t, filepath.Dir(path), filepath.Base(path), base+step.file)
+
+		err := reloader.Reload()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: Reload = %v, want error %v", step.name, err, step.wantErr)
+		}
+
+		c := live.Get()
+		if c.LogLevel != step.wantLevel || c.GrpcPort != step.wantPort {
+			t.Errorf("%s: log level %s, grpc port %d, want %s, %d", step.name, c.LogLevel, c.GrpcPort, step.wantLevel, step.wantPort)
+		}
+		if hooked := len(hooked) > hooks; hooked != step.wantHook {
+			t.Errorf("%s: hook called = %v, want %v", step.name, hooked, step.wantHook)
+		}
+		if step.wantHook && hooked[len(hooked)-1] != c {
+			t.Errorf("%s: hook got another config than the live one", step.name)
+		}
+		// A Config returned by Get never changes.
+		if !step.wantHook && c != before {
+			t.Errorf("%s: config replaced without a change", step.name)
+		}
+	}
+
+	if source := live.Get().Source("LOG_LEVEL"); source != "file "+path {
+		t.Errorf("source of LOG_LEVEL = %q", source)
+	}
+}
+
+// Reloaded settings are checked against the ones kept until the restart.
+func TestReloadConflictWithKeptSetting(t *testing.T) {
+	base := "app_key: " + testAppKey + "\n"
+	reloader, live, path := newTestReloader(t, base+"cors_allow_credentials: true\ncors_allowed_origins: [https://app.example.com]\n")
+
+	// Valid on its own, but the kept CORS_ALLOW_CREDENTIALS rejects "*".
+	writeFile(t, filepath.Dir(path), filepath.Base(path), base+"cors_allow_credentials: false\ncors_allowed_origins: ['*']\n")
+	if err := reloader.Reload(); err == nil {
+		t.Error("Reload succeeded, want error")
+	}
+
+	if origins := live.Get().CorsAllowedOrigins; len(origins) != 1 || origins[0] != "https://app.example.com" {
+		t.Errorf("origins = %v", origins)
+	}
+}
+
+// Run reloads when the config file is replaced.
+func TestRunReloadsOnFileChange(t *testing.T) {
+	base := "app_key: " + testAppKey + "\n"
+	reloader, live, path := newTestReloader(t, base+"log_level: info\n")
+
+	var mu sync.Mutex
+	reloaded := make(chan struct{})
+	reloader.OnReload(func(c *Config) {
+		mu.Lock()
+		defer mu.Unlock()
+		if c.LogLevel == "debug" {
+			close(reloaded)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- reloader.Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// Give the watcher time to start before the change.
+	time.Sleep(50 * time.Millisecond)
+	writeFile(t, filepath.Dir(path), filepath.Base(path), base+"log_level: debug\n")
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("not reloaded, log level %s", live.Get().LogLevel)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redacted = "******"
+
+// Setting is one setting of a Config as shown to operators.
+type Setting struct {
+	Key string
+	// Value is formatted like in the environment, secrets are redacted.
+	Value  string
+	Source string
+	// Reload is set for settings a Reloader changes while the service runs.
+	Reload bool
+}
+
+// Settings returns every setting of c in declaration order.
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	c.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		settings = append(settings, Setting{
+			Key:    key,
+			Value:  formatValue(field, value),
+			Source: c.Source(key),
+			Reload: field.Tag.Get("reload") == "true",
+		})
+	})
+
+	return settings
+}
+
+// AsString returns the settings as JSON keyed by environment variable, with
+// the values of secret settings replaced.
+func (c *Config) AsString() string {
+	values := map[string]interface{}{}
+	c.fields(func(key string, field reflect.StructField, value reflect.Value) {
+		switch {
+		case field.Tag.Get("secret") == "true":
+			values[key] = redact(value.String())
+		case value.Type() == durationType:
+			values[key] = time.Duration(value.Int()).String()
+		default:
+			values[key] = value.Interface()
+		}
+	})
+
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+func formatValue(field reflect.StructField, value reflect.Value) string {
+	switch {
+	case field.Tag.Get("secret") == "true":
+		return redact(value.String())
+	case value.Type() == durationType:
+		return time.Duration(value.Int()).String()
+	case value.Kind() == reflect.Slice:
+		return strings.Join(value.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// redact hides a secret value. Of URLs only the password is hidden, as they
+// are only secret for the credentials they may carry.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+
+	return redacted
+}
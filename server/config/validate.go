@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go-auth/server/lib/hasher"
+	"go-auth/server/lib/passwordpolicy"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// An APP_KEY has to be at least this long and estimated this strong, e.g.
+	// 32 random bytes encoded in hex or base64.
+	minAppKeyBytes       = 32
+	minAppKeyEntropyBits = 128
+)
+
+// Validate reports every setting that keeps the service from running safely.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch {
+	case c.AppKey == "":
+		errs = append(errs, errors.New("APP_KEY is required"))
+	case len(c.AppKey) < minAppKeyBytes:
+		errs = append(errs, fmt.Errorf("APP_KEY must be at least %d bytes long", minAppKeyBytes))
+	case passwordpolicy.Entropy(c.AppKey) < minAppKeyEntropyBits:
+		errs = append(errs, errors.New("APP_KEY is too easy to guess, use a random value"))
+	}
+
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL %q is not a log level", c.LogLevel))
+	}
+
+	switch c.DatabaseDriver {
+	case "mysql", "postgres", "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be mysql, postgres or sqlite, not %q", c.DatabaseDriver))
+	}
+
+	check(validPort(c.GrpcPort), "GRPC_PORT %d is not a valid port", c.GrpcPort)
+	check(validPort(c.HttpPort), "HTTP_PORT %d is not a valid port", c.HttpPort)
+	check(c.GrpcPort != c.HttpPort, "GRPC_PORT and HTTP_PORT must differ")
+	for _, host := range c.ListenAddress {
+		_, _, err := net.SplitHostPort(host)
+		check(err != nil, "LISTEN_ADDRESS %q must not contain a port, use GRPC_PORT and HTTP_PORT", host)
+	}
+
+	for _, origin := range c.CorsAllowedOrigins {
+		check(origin != "*" || !c.CorsAllowCredentials, "CORS_ALLOWED_ORIGINS must list origins instead of * when CORS_ALLOW_CREDENTIALS is set")
+		check(validOrigin(origin), "CORS_ALLOWED_ORIGINS %q is not * or an origin like https://app.example.com", origin)
+	}
+	check(c.MetricsAddress == "" || loopbackAddress(c.MetricsAddress), "METRICS_ADDRESS %q must be a loopback address with port, e.g. 127.0.0.1:9090", c.MetricsAddress)
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.HealthCheckInterval > 0, "HEALTH_CHECK_INTERVAL must be positive")
+	check(c.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive")
+	check(c.CorsMaxAge >= 0, "CORS_MAX_AGE must not be negative")
+
+	check(c.ElasticsearchUrl != "", "ELASTICSEARCH_URL is required")
+	check(c.RabbitMqUrl != "", "RABBITMQ_URL is required")
+
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"ACCESS_TOKEN_DURATION", c.AccessTokenDuration},
+		{"REFRESH_TOKEN_DURATION", c.RefreshTokenDuration},
+		{"REVOCATION_SYNC_INTERVAL", c.RevocationSyncInterval},
+		{"PASSWORD_RESET_TOKEN_DURATION", c.PasswordResetTokenDuration},
+		{"EMAIL_VERIFICATION_TOKEN_DURATION", c.EmailVerificationTokenDuration},
+	} {
+		check(d.value > 0, "%s must be positive", d.key)
+	}
+	check(c.RefreshTokenDuration >= c.AccessTokenDuration, "REFRESH_TOKEN_DURATION must not be shorter than ACCESS_TOKEN_DURATION")
+
+	check(c.LoginMaxAttempts > 0, "LOGIN_MAX_ATTEMPTS must be positive")
+	check(c.LoginMaxAttemptsPerIp > 0, "LOGIN_MAX_ATTEMPTS_PER_IP must be positive")
+	check(c.LoginFreeAttempts >= 0, "LOGIN_FREE_ATTEMPTS must not be negative")
+	check(c.LoginBackoffBase > 0, "LOGIN_BACKOFF_BASE must be positive")
+	check(c.LoginBackoffMax >= c.LoginBackoffBase, "LOGIN_BACKOFF_MAX must not be shorter than LOGIN_BACKOFF_BASE")
+	check(c.LoginLockoutDuration > 0, "LOGIN_LOCKOUT_DURATION must be positive")
+
+	switch c.Notifier {
+	case "", "log":
+	case "file":
+		check(c.NotifierFilePath != "", "NOTIFIER_FILE_PATH is required when NOTIFIER is file")
+	default:
+		errs = append(errs, fmt.Errorf("NOTIFIER must be log or file, not %q", c.Notifier))
+	}
+	check(validLinkTemplate(c.PasswordResetUrl), "PASSWORD_RESET_URL %q must be an absolute URL containing %%s once", c.PasswordResetUrl)
+	check(validLinkTemplate(c.EmailVerificationUrl), "EMAIL_VERIFICATION_URL %q must be an absolute URL containing %%s once", c.EmailVerificationUrl)
+
+	check(c.PasswordMinLength >= 0, "PASSWORD_MIN_LENGTH must not be negative")
+	check(c.PasswordMaxBytes >= 0, "PASSWORD_MAX_BYTES must not be negative")
+	check(c.PasswordMaxBytes == 0 || c.PasswordMaxBytes >= c.PasswordMinLength, "PASSWORD_MAX_BYTES must not be smaller than PASSWORD_MIN_LENGTH")
+	check(c.PasswordMinCharClasses >= 0 && c.PasswordMinCharClasses <= 4, "PASSWORD_MIN_CHAR_CLASSES must be between 0 and 4")
+	check(c.PasswordMinEntropyBits >= 0, "PASSWORD_MIN_ENTROPY_BITS must not be negative")
+
+	// The hashers take unsigned parameters, negative values would wrap
+	// around instead of failing.
+	switch c.PasswordHasher {
+	case hasher.Argon2idID, hasher.ScryptID, hasher.Pbkdf2Sha256ID, hasher.BcryptID:
+	default:
+		errs = append(errs, fmt.Errorf("PASSWORD_HASHER must be argon2id, scrypt, pbkdf2-sha256 or bcrypt, not %q", c.PasswordHasher))
+	}
+	check(c.Argon2Iterations >= 1 && c.Argon2Iterations <= hasher.MaxArgon2Iterations, "ARGON2_ITERATIONS must be between 1 and %d", hasher.MaxArgon2Iterations)
+	check(c.Argon2Parallelism >= 1 && c.Argon2Parallelism <= math.MaxUint8, "ARGON2_PARALLELISM must be between 1 and %d", math.MaxUint8)
+	check(c.Argon2Memory >= 8*c.Argon2Parallelism && c.Argon2Memory <= hasher.MaxArgon2Memory, "ARGON2_MEMORY must be between 8 KiB per ARGON2_PARALLELISM and %d KiB", hasher.MaxArgon2Memory)
+	check(c.ScryptCostLog2 >= 1 && c.ScryptCostLog2 <= hasher.MaxScryptCostLog2 && 128<<c.ScryptCostLog2*hasher.ScryptBlockSize <= hasher.MaxScryptMemory,
+		"SCRYPT_COST_LOG2 must be positive and use at most %d MiB", hasher.MaxScryptMemory>>20)
+	check(c.Pbkdf2Iterations >= hasher.MinPbkdf2Iterations && c.Pbkdf2Iterations <= hasher.MaxPbkdf2Iterations, "PBKDF2_ITERATIONS must be between %d and %d", hasher.MinPbkdf2Iterations, hasher.MaxPbkdf2Iterations)
+	check(c.BcryptCost >= bcrypt.MinCost && c.BcryptCost <= hasher.MaxBcryptCost, "BCRYPT_COST must be between %d and %d", bcrypt.MinCost, hasher.MaxBcryptCost)
+
+	check(c.HashWorkers > 0, "HASH_WORKERS must be positive")
+	check(c.HashQueueSize >= 0, "HASH_QUEUE_SIZE must not be negative")
+
+	return errors.Join(errs...)
+}
+
+// validOrigin reports whether origin is "*" or a scheme and host, where the
+// host may start with a "*." wildcard.
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	return err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.User == nil
+}
+
+// validLinkTemplate reports whether link is empty or an absolute URL with one
+// %s placeholder for a token.
+func validLinkTemplate(link string) bool {
+	if link == "" {
+		return true
+	}
+
+	u, err := url.Parse(strings.Replace(link, "%s", "token", 1))
+	return err == nil && strings.Count(link, "%s") == 1 && u.Scheme != "" && u.Host != ""
+}
+
+// loopbackAddress reports whether address is a port on localhost or a
+// loopback IP.
+func loopbackAddress(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || port == "" {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	return host == "localhost" || (ip != nil && ip.IsLoopback())
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
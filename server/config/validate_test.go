@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAppKey = "Zq8x!Lm2#Vb7@Rt5$Wn1^Kp4&Hs9*Dj3"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		// Key named in the error, empty when the settings are valid.
+		wantKey string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"missing app key", func(c *Config) { c.AppKey = "" }, "APP_KEY"},
+		{"guessable app key", func(c *Config) { c.AppKey = strings.Repeat("a", 40) }, "APP_KEY"},
+		{"port in listen address", func(c *Config) { c.ListenAddress = []string{"127.0.0.1:80"} }, "LISTEN_ADDRESS"},
+		{"wildcard origin with credentials", func(c *Config) { c.CorsAllowCredentials = true }, "CORS_ALLOWED_ORIGINS"},
+		{"public metrics address", func(c *Config) { c.MetricsAddress = "0.0.0.0:9090" }, "METRICS_ADDRESS"},
+		{"zero argon2 iterations", func(c *Config) { c.Argon2Iterations = 0 }, "ARGON2_ITERATIONS"},
+		{"negative argon2 memory", func(c *Config) { c.Argon2Memory = -1 }, "ARGON2_MEMORY"},
+		{"argon2 memory below lanes", func(c *Config) { c.Argon2Memory = 8; c.Argon2Parallelism = 4 }, "ARGON2_MEMORY"},
+		{"argon2 parallelism above uint8", func(c *Config) { c.Argon2Parallelism = 256 }, "ARGON2_PARALLELISM"},
+		{"zero argon2 parallelism", func(c *Config) { c.Argon2Parallelism = 0 }, "ARGON2_PARALLELISM"},
+		{"negative scrypt cost", func(c *Config) { c.ScryptCostLog2 = -1 }, "SCRYPT_COST_LOG2"},
+		{"scrypt cost beyond memory", func(c *Config) { c.ScryptCostLog2 = 23 }, "SCRYPT_COST_LOG2"},
+		{"few pbkdf2 iterations", func(c *Config) { c.Pbkdf2Iterations = 1 }, "PBKDF2_ITERATIONS"},
+		{"bcrypt cost too low", func(c *Config) { c.BcryptCost = 3 }, "BCRYPT_COST"},
+		{"bcrypt cost too high", func(c *Config) { c.BcryptCost = 31 }, "BCRYPT_COST"},
+		{"unknown hasher", func(c *Config) { c.PasswordHasher = "md5" }, "PASSWORD_HASHER"},
+		{"zero login attempts", func(c *Config) { c.LoginMaxAttempts = 0 }, "LOGIN_MAX_ATTEMPTS"},
+		{"negative login attempts per ip", func(c *Config) { c.LoginMaxAttemptsPerIp = -1 }, "LOGIN_MAX_ATTEMPTS_PER_IP"},
+		{"negative free attempts", func(c *Config) { c.LoginFreeAttempts = -1 }, "LOGIN_FREE_ATTEMPTS"},
+		{"backoff max below base", func(c *Config) { c.LoginBackoffMax = time.Millisecond }, "LOGIN_BACKOFF_MAX"},
+		{"zero lockout", func(c *Config) { c.LoginLockoutDuration = 0 }, "LOGIN_LOCKOUT_DURATION"},
+		{"unknown notifier", func(c *Config) { c.Notifier = "smtp" }, "NOTIFIER"},
+		{"file notifier without path", func(c *Config) { c.Notifier = "file"; c.NotifierFilePath = "" }, "NOTIFIER_FILE_PATH"},
+		{"reset link without token", func(c *Config) { c.PasswordResetUrl = "https://example.com/reset" }, "PASSWORD_RESET_URL"},
+		{"relative verification link", func(c *Config) { c.EmailVerificationUrl = "/verify?token=%s" }, "EMAIL_VERIFICATION_URL"},
+		{"valid links", func(c *Config) {
+			c.PasswordResetUrl = "https://example.com/reset?token=%s"
+			c.EmailVerificationUrl = "https://example.com/verify/%s"
+		}, ""},
+		{"too many char classes", func(c *Config) { c.PasswordMinCharClasses = 5 }, "PASSWORD_MIN_CHAR_CLASSES"},
+		{"max bytes below min length", func(c *Config) { c.PasswordMinLength = 20; c.PasswordMaxBytes = 10 }, "PASSWORD_MAX_BYTES"},
+		{"refresh shorter than access", func(c *Config) { c.RefreshTokenDuration = time.Minute }, "REFRESH_TOKEN_DURATION"},
+		{"zero hash workers", func(c *Config) { c.HashWorkers = 0 }, "HASH_WORKERS"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Default()
+			c.AppKey = testAppKey
+			tc.change(c)
+
+			err := c.Validate()
+			if tc.wantKey == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantKey+" ") {
+				t.Fatalf("Validate = %v, want an error about %s", err, tc.wantKey)
+			}
+			if n := len(strings.Split(err.Error(), "\n")); n != 1 {
+				t.Errorf("Validate reported %d errors, want 1: %v", n, err)
+			}
+		})
+	}
+}
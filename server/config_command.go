@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go-auth/server/config"
+	"os"
+	"text/tabwriter"
+)
+
+const configUsage = "usage: config print"
+
+// runConfig runs the config subcommand:
+//
+//	config print  list the effective settings and where they come from
+//
+// Settings marked with reload change on SIGHUP or when the config file is
+// written, the others need a restart.
+func runConfig(c *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tRELOAD")
+	for _, setting := range c.Settings() {
+		reload := ""
+		if setting.Reload {
+			reload = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source, reload)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Printing works with invalid settings, so they can be inspected.
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package database opens the database of the service with one of the
+// supported drivers.
+package database
+
+import (
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Supported drivers.
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	// SQLite is embedded, the DSN is the path of the database file or
+	// ":memory:".
+	SQLite = "sqlite"
+)
+
+// DefaultDSN returns the DSN used for driver when none is configured.
+func DefaultDSN(driver string) string {
+	switch driver {
+	case Postgres:
+		return "host=127.0.0.1 port=5432 user=go_user password=go_password dbname=go_auth sslmode=disable"
+	case SQLite:
+		return "go_auth.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	default:
+		return "go_user:go_password@tcp(127.0.0.1:3306)/go_auth?charset=utf8mb4&parseTime=True&loc=Local"
+	}
+}
+
+// Open connects to the database at dsn using driver.
+func Open(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case MySQL:
+		dialector = mysql.Open(dsn)
+	case Postgres:
+		dialector = postgres.Open(dsn)
+	case SQLite:
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	// TranslateError turns driver errors such as duplicate keys into gorm
+	// errors, which the API maps to gRPC status codes.
+	return gorm.Open(dialector, &gorm.Config{TranslateError: true})
+}
@@ -0,0 +1,166 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-auth/server/pb"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// stubUserService answers from the request alone: GetUser echoes the id and
+// the authorization metadata, LoginUser accepts alice.
+type stubUserService struct {
+	pb.UnimplementedUserServiceServer
+}
+
+func (stubUserService) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization token is not provided")
+	}
+
+	return &pb.GetUserResponse{Id: req.GetId(), Name: md.Get("authorization")[0]}, nil
+}
+
+func (stubUserService) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.GetName() != "alice" {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	return &pb.LoginUserResponse{Code: http.StatusOK, Message: "Success", AccessToken: "access"}, nil
+}
+
+// newTestGateway serves stubUserService over gRPC and returns the gateway in
+// front of it.
+func newTestGateway(t *testing.T) http.Handler {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterUserServiceServer(server, stubUserService{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	handler, err := New(ctx, lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handler
+}
+
+func TestGateway(t *testing.T) {
+	handler := newTestGateway(t)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		authorization string
+		wantStatus    int
+		// wantBody maps JSON fields to their expected value.
+		wantBody map[string]interface{}
+	}{
+		{"login", http.MethodPost, "/v1/auth/login", `{"name":"alice","password":"secret"}`, "", http.StatusOK,
+			map[string]interface{}{"access_token": "access", "message": "Success", "mfa_required": false}},
+		{"legacy login route", http.MethodPost, "/login", `{"name":"alice"}`, "", http.StatusOK,
+			map[string]interface{}{"access_token": "access"}},
+		{"unknown fields are ignored", http.MethodPost, "/v1/auth/login", `{"name":"alice","remember":true}`, "", http.StatusOK,
+			map[string]interface{}{"access_token": "access"}},
+		{"invalid argument", http.MethodPost, "/v1/auth/login", `{}`, "", http.StatusBadRequest,
+			map[string]interface{}{"code": float64(codes.InvalidArgument), "message": "name is required"}},
+		{"unauthenticated", http.MethodPost, "/v1/auth/login", `{"name":"bob"}`, "", http.StatusUnauthorized,
+			map[string]interface{}{"code": float64(codes.Unauthenticated)}},
+		{"malformed body", http.MethodPost, "/v1/auth/login", `{"name":`, "", http.StatusBadRequest, nil},
+		{"path parameter and authorization", http.MethodGet, "/v1/users/01ARZ3NDEKTSV4RRFFQ69G5FAV", "", "Bearer token", http.StatusOK,
+			map[string]interface{}{"id": "01ARZ3NDEKTSV4RRFFQ69G5FAV", "name": "Bearer token"}},
+		{"missing authorization", http.MethodGet, "/v1/users/01ARZ3NDEKTSV4RRFFQ69G5FAV", "", "", http.StatusUnauthorized, nil},
+		{"unimplemented", http.MethodPost, "/v1/auth/logout", `{}`, "", http.StatusNotImplemented, nil},
+		{"unknown route", http.MethodGet, "/v1/nothing", "", "", http.StatusNotFound, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tc.wantStatus, rec.Body)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q: %v", rec.Body, err)
+			}
+			for field, want := range tc.wantBody {
+				if got := body[field]; got != want {
+					t.Errorf("%s = %v, want %v", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorHandlerRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay time.Duration
+		want  string
+	}{
+		{"whole seconds", 30 * time.Second, "30"},
+		{"rounded up", 1500 * time.Millisecond, "2"},
+		{"no retry info", 0, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.New(codes.ResourceExhausted, "too many attempts")
+			if tc.delay > 0 {
+				var err error
+				st, err = st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(tc.delay)})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil)
+			errorHandler(context.Background(), runtime.NewServeMux(), &runtime.JSONPb{}, rec, req, st.Err())
+
+			if rec.Code != http.StatusTooManyRequests {
+				t.Errorf("status %d, want %d", rec.Code, http.StatusTooManyRequests)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tc.want {
+				t.Errorf("Retry-After = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
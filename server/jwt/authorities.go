@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"go-auth/server/models"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Authorities are the roles and permissions embedded in access tokens.
+type Authorities struct {
+	Roles       []string
+	Permissions []string
+}
+
+// AuthorityResolver looks up the current roles and permissions of a user. It is
+// consulted on every token issue, including refresh, so role changes reach the
+// user with the next rotation.
+type AuthorityResolver interface {
+	Authorities(userID string) (*Authorities, error)
+}
+
+type gormAuthorityResolver struct {
+	db *gorm.DB
+}
+
+func NewGormAuthorityResolver(db *gorm.DB) AuthorityResolver {
+	return &gormAuthorityResolver{db: db}
+}
+
+func (r *gormAuthorityResolver) Authorities(userID string) (*Authorities, error) {
+	user := &models.User{}
+	if err := r.db.Preload("Roles.Permissions").Where("public_id = ?", userID).First(user).Error; err != nil {
+		return nil, err
+	}
+
+	authorities := &Authorities{}
+	permissions := map[string]bool{}
+	for _, role := range user.Roles {
+		authorities.Roles = append(authorities.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			if !permissions[permission.Name] {
+				permissions[permission.Name] = true
+				authorities.Permissions = append(authorities.Permissions, permission.Name)
+			}
+		}
+	}
+
+	sort.Strings(authorities.Roles)
+	sort.Strings(authorities.Permissions)
+
+	return authorities, nil
+}
@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"go-auth/server/models"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const AdminRole = models.AdminRole
+
+// Policy describes who may call a gRPC method. Public methods need no token,
+// every other method needs a valid access token and, when Roles is set, at
+// least one of the listed roles. Every permission in Permissions is required.
+type Policy struct {
+	Public      bool
+	Roles       []string
+	Permissions []string
+}
+
+var (
+	PublicPolicy        = Policy{Public: true}
+	AuthenticatedPolicy = Policy{}
+	AdminPolicy         = Policy{Roles: []string{AdminRole}}
+)
+
+// RequirePermission returns a policy granting access to callers holding all
+// of the given permissions.
+func RequirePermission(permissions ...string) Policy {
+	return Policy{Permissions: permissions}
+}
+
+type claimsContextKey struct{}
+
+// ContextWithClaims returns a copy of ctx carrying the verified token claims.
+func ContextWithClaims(ctx context.Context, claims *UserClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by the auth interceptor.
+func ClaimsFromContext(ctx context.Context) (*UserClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*UserClaims)
+	return claims, ok && claims != nil
+}
+
+type AuthInterceptor struct {
+	jwtManager *JWTManager
+	policies   map[string]Policy
+	logger     *logrus.Logger
+}
+
+// NewAuthInterceptor creates an interceptor enforcing policies, keyed by full
+// gRPC method name. Methods missing from the table are rejected.
+func NewAuthInterceptor(jwtManager *JWTManager, policies map[string]Policy, logger *logrus.Logger) *AuthInterceptor {
+	return &AuthInterceptor{
+		jwtManager: jwtManager,
+		policies:   policies,
+		logger:     logger,
+	}
+}
+
+func (interceptor *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := interceptor.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (interceptor *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := interceptor.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func (interceptor *AuthInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	policy, ok := interceptor.policies[method]
+	if !ok {
+		interceptor.logger.Warn("No access policy for method: ", method)
+		return nil, status.Error(codes.PermissionDenied, "no permission to access this RPC")
+	}
+
+	if policy.Public {
+		return ctx, nil
+	}
+
+	accessToken, err := interceptor.jwtManager.GetAccessToken(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "access token is not provided")
+	}
+
+	claims, err := interceptor.jwtManager.Verify(*accessToken)
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) {
+		// The reason stays in the log, it may describe keys or claims.
+		interceptor.logger.Debug("Rejected access token: ", err)
+		return nil, status.Error(codes.Unauthenticated, "access token is invalid")
+	}
+	if err != nil {
+		interceptor.logger.Error("Failed to verify access token: ", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	if len(policy.Roles) > 0 && !claims.HasAnyRole(policy.Roles...) {
+		return nil, status.Error(codes.PermissionDenied, "no permission to access this RPC")
+	}
+
+	if !claims.HasPermissions(policy.Permissions...) {
+		return nil, status.Error(codes.PermissionDenied, "no permission to access this RPC")
+	}
+
+	return ContextWithClaims(ctx, claims), nil
+}
+
+// authServerStream overrides the stream context so handlers see the claims.
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testStream is a server stream carrying only a context.
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+
+	admin := &models.Role{Name: AdminRole, Permissions: []models.Permission{{Name: "users:manage"}, {Name: "roles:manage"}}}
+	support := &models.Role{Name: "support", Permissions: []models.Permission{{Name: "users:read"}}}
+	for _, role := range []*models.Role{admin, support} {
+		if err := db.Create(role).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tokenOf := func(name string, roles ...*models.Role) string {
+		user := &models.User{Name: name, Password: "hash", Roles: []models.Role{}}
+		for _, role := range roles {
+			user.Roles = append(user.Roles, *role)
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+		token, err := manager.Generate(user.PublicId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token.AccessToken
+	}
+	adminToken := tokenOf("admin", admin)
+	supportToken := tokenOf("support", support)
+	userToken := tokenOf("user")
+
+	revokedToken := tokenOf("revoked")
+	if err := manager.Revoke(revokedToken, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	interceptor := NewAuthInterceptor(manager, map[string]Policy{
+		"/public":        PublicPolicy,
+		"/authenticated": AuthenticatedPolicy,
+		"/admin":         AdminPolicy,
+		"/users":         RequirePermission("users:read"),
+		"/manage":        RequirePermission("users:manage", "roles:manage"),
+		"/support":       {Roles: []string{"support", AdminRole}, Permissions: []string{"users:read"}},
+	}, logger)
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		want          codes.Code
+	}{
+		{"public without token", "/public", "", codes.OK},
+		{"unknown method", "/unknown", "Bearer " + adminToken, codes.PermissionDenied},
+		{"missing token", "/authenticated", "", codes.Unauthenticated},
+		{"invalid token", "/authenticated", "Bearer garbage", codes.Unauthenticated},
+		{"revoked token", "/authenticated", "Bearer " + revokedToken, codes.Unauthenticated},
+		{"authenticated", "/authenticated", "Bearer " + userToken, codes.OK},
+		{"token without scheme", "/authenticated", userToken, codes.OK},
+		{"admin", "/admin", "Bearer " + adminToken, codes.OK},
+		{"admin without role", "/admin", "Bearer " + supportToken, codes.PermissionDenied},
+		{"permission", "/users", "Bearer " + supportToken, codes.OK},
+		{"missing permission", "/users", "Bearer " + userToken, codes.PermissionDenied},
+		{"all permissions", "/manage", "Bearer " + adminToken, codes.OK},
+		{"some permissions", "/manage", "Bearer " + supportToken, codes.PermissionDenied},
+		{"role and permission", "/support", "Bearer " + supportToken, codes.OK},
+		{"role without permission", "/support", "Bearer " + adminToken, codes.PermissionDenied},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tc.authorization))
+			}
+
+			var unaryClaims *UserClaims
+			_, err := interceptor.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				unaryClaims, _ = ClaimsFromContext(ctx)
+				return nil, nil
+			})
+			if code := status.Code(err); code != tc.want {
+				t.Errorf("unary: code = %s, want %s (%v)", code, tc.want, err)
+			}
+
+			var streamClaims *UserClaims
+			err = interceptor.Stream()(nil, &testStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tc.method}, func(srv interface{}, stream grpc.ServerStream) error {
+				streamClaims, _ = ClaimsFromContext(stream.Context())
+				return nil
+			})
+			if code := status.Code(err); code != tc.want {
+				t.Errorf("stream: code = %s, want %s (%v)", code, tc.want, err)
+			}
+
+			// Handlers of protected methods see the claims of the caller.
+			wantClaims := tc.want == codes.OK && tc.method != "/public"
+			if (unaryClaims != nil) != wantClaims || (streamClaims != nil) != wantClaims {
+				t.Errorf("claims in unary handler %v, in stream handler %v, want %v", unaryClaims != nil, streamClaims != nil, wantClaims)
+			}
+		})
+	}
+}
+
+// failingRevocationStore fails every lookup, like a store whose database is
+// down.
+type failingRevocationStore struct {
+	RevocationStore
+}
+
+func (failingRevocationStore) IsRevoked(jti string, userID string, issuedAt time.Time) (bool, error) {
+	return false, errors.New("database is locked")
+}
+
+// Clients learn that a token was rejected, not why.
+func TestAuthInterceptorErrors(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+	token, err := manager.Generate(migrationstest.CreateUser(t, db, "alice").PublicId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := manager.Generate(migrationstest.CreateUser(t, db, "bob").PublicId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Revoke(revoked.AccessToken, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	failing := NewJWTManager(NewHMACKeyRing(testSecret), time.Minute, time.Hour, NewGormRefreshTokenStore(db), failingRevocationStore{}, NewGormAuthorityResolver(db), logger)
+	policies := map[string]Policy{"/authenticated": AuthenticatedPolicy}
+
+	tests := []struct {
+		name        string
+		manager     *JWTManager
+		token       string
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{"malformed token", manager, "garbage", codes.Unauthenticated, "access token is invalid"},
+		{"other signing key", manager, signedWith(t, "other-secret"), codes.Unauthenticated, "access token is invalid"},
+		{"revoked token", manager, revoked.AccessToken, codes.Unauthenticated, "access token is invalid"},
+		{"revocation store failure", failing, token.AccessToken, codes.Internal, "internal error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+tc.token))
+			_, err := NewAuthInterceptor(tc.manager, policies, logger).Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/authenticated"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+
+			st := status.Convert(err)
+			if st.Code() != tc.wantCode || st.Message() != tc.wantMessage {
+				t.Errorf("got %s %q, want %s %q", st.Code(), st.Message(), tc.wantCode, tc.wantMessage)
+			}
+		})
+	}
+}
+
+// signedWith returns an access token signed with secret.
+func signedWith(t *testing.T, secret string) string {
+	t.Helper()
+
+	db := migrationstest.Open(t)
+	token, err := newTestManager(t, db, NewHMACKeyRing(secret)).Generate(migrationstest.CreateUser(t, db, "mallory").PublicId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token.AccessToken
+}
@@ -0,0 +1,269 @@
+package manager
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// Key is a single key in the KeyRing. Private is only set for keys that can
+// sign tokens; verification-only keys carry just the public half.
+type Key struct {
+	Kid     string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeyRing holds the key used to sign new tokens and every key that is still
+// accepted when verifying them. Keeping retired keys in the ring lets tokens
+// signed before a rotation stay valid until they expire.
+type KeyRing struct {
+	signing      *Key
+	verification map[string]*Key
+}
+
+// JSONWebKey is the public part of a key as described by RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewHMACKeyRing returns a key ring signing with HS256 and a shared secret.
+// HMAC keys are never published in the JWKS.
+func NewHMACKeyRing(secretKey string) *KeyRing {
+	key := &Key{
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secretKey),
+		Public:  []byte(secretKey),
+	}
+
+	return &KeyRing{
+		signing:      key,
+		verification: map[string]*Key{"": key},
+	}
+}
+
+// LoadKeyRing reads the PEM encoded signing key and any additional
+// verification keys. The algorithm is derived from the key type: RSA keys sign
+// with RS256, P-256 keys with ES256, P-384 keys with ES384 and Ed25519 keys
+// with EdDSA.
+func LoadKeyRing(signingKeyPath string, verificationKeyPaths []string) (*KeyRing, error) {
+	signing, err := loadKeyFile(signingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("%s does not contain a private key", signingKeyPath)
+	}
+
+	ring := &KeyRing{
+		signing:      signing,
+		verification: map[string]*Key{signing.Kid: signing},
+	}
+
+	for _, path := range verificationKeyPaths {
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ring.verification[key.Kid] = key
+	}
+
+	return ring, nil
+}
+
+func (ring *KeyRing) SigningKey() *Key {
+	return ring.signing
+}
+
+// VerificationKey returns the key for the kid found in a token header.
+func (ring *KeyRing) VerificationKey(kid string) (*Key, error) {
+	key, ok := ring.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set.
+func (ring *KeyRing) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range ring.verification {
+		jwk, ok := publicJWK(key)
+		if ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", path)
+	}
+
+	key, err := parseKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+func parseKey(block *pem.Block) (*Key, error) {
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if private != nil {
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		public = signer.Public()
+	}
+
+	key := &Key{Private: private, Public: public}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+
+	jwk, _ := publicJWK(key)
+	key.Kid = thumbprint(jwk)
+
+	return key, nil
+}
+
+func publicJWK(key *Key) (JSONWebKey, bool) {
+	jwk := JSONWebKey{
+		Kid: key.Kid,
+		Use: "sig",
+		Alg: key.Method.Alg(),
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeSegment(pub.N.Bytes())
+		jwk.E = encodeSegment(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encodeSegment(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeSegment(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeSegment(pub)
+	default:
+		return JSONWebKey{}, false
+	}
+
+	return jwk, true
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, used as the key id.
+func thumbprint(jwk JSONWebKey) string {
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return encodeSegment(sum[:])
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
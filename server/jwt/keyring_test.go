@@ -0,0 +1,322 @@
+package manager
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-auth/server/migrations/migrationstest"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// writeKey writes key PEM encoded as blockType to a file in dir and returns
+// its path.
+func writeKey(t *testing.T, dir string, name string, blockType string, key interface{}) string {
+	t.Helper()
+
+	var der []byte
+	var err error
+	switch blockType {
+	case "PRIVATE KEY":
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	case "RSA PRIVATE KEY":
+		der = x509.MarshalPKCS1PrivateKey(key.(*rsa.PrivateKey))
+	case "EC PRIVATE KEY":
+		der, err = x509.MarshalECPrivateKey(key.(*ecdsa.PrivateKey))
+	case "PUBLIC KEY":
+		der, err = x509.MarshalPKIXPublicKey(key)
+	case "RSA PUBLIC KEY":
+		der = x509.MarshalPKCS1PublicKey(key.(*rsa.PublicKey))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(dir, "not-pem")
+	if err := os.WriteFile(notPEM, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantAlg string
+		wantErr bool
+	}{
+		{"rsa pkcs1", writeKey(t, dir, "rsa1", "RSA PRIVATE KEY", rsaKey), "RS256", false},
+		{"rsa pkcs8", writeKey(t, dir, "rsa8", "PRIVATE KEY", rsaKey), "RS256", false},
+		{"p-256", writeKey(t, dir, "p256", "EC PRIVATE KEY", p256), "ES256", false},
+		{"p-384", writeKey(t, dir, "p384", "PRIVATE KEY", p384), "ES384", false},
+		{"ed25519", writeKey(t, dir, "ed25519", "PRIVATE KEY", edKey), "EdDSA", false},
+		{"p-521", writeKey(t, dir, "p521", "EC PRIVATE KEY", p521), "", true},
+		{"public key only", writeKey(t, dir, "public", "PUBLIC KEY", rsaKey.Public()), "", true},
+		{"not pem", notPEM, "", true},
+		{"missing file", filepath.Join(dir, "missing"), "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ring, err := LoadKeyRing(tc.path, nil)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("LoadKeyRing succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key := ring.SigningKey()
+			if key.Method.Alg() != tc.wantAlg {
+				t.Errorf("alg = %s, want %s", key.Method.Alg(), tc.wantAlg)
+			}
+			if key.Kid == "" {
+				t.Error("kid is empty")
+			}
+		})
+	}
+}
+
+// The key id only depends on the public key, so a key is recognised whatever
+// its encoding.
+func TestKeyID(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ring, err := LoadKeyRing(writeKey(t, dir, "signing", "RSA PRIVATE KEY", rsaKey), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid := ring.SigningKey().Kid
+
+	tests := []struct {
+		name      string
+		blockType string
+		key       interface{}
+	}{
+		{"pkcs8 private key", "PRIVATE KEY", rsaKey},
+		{"pkix public key", "PUBLIC KEY", rsaKey.Public()},
+		{"pkcs1 public key", "RSA PUBLIC KEY", rsaKey.Public()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := loadKeyFile(writeKey(t, dir, tc.name, tc.blockType, tc.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Kid != kid {
+				t.Errorf("kid = %s, want %s", key.Kid, kid)
+			}
+		})
+	}
+}
+
+// After a rotation tokens signed with the retired key stay valid as long as
+// its public key is configured for verification.
+func TestKeyRotation(t *testing.T) {
+	db := migrationstest.Open(t)
+	userID := migrationstest.CreateUser(t, db, "alice").PublicId
+	dir := t.TempDir()
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPrivate := writeKey(t, dir, "old", "EC PRIVATE KEY", oldKey)
+	oldPublic := writeKey(t, dir, "old.pub", "PUBLIC KEY", oldKey.Public())
+	newPrivate := writeKey(t, dir, "new", "PRIVATE KEY", newKey)
+
+	ring := func(signing string, verification ...string) *KeyRing {
+		ring, err := LoadKeyRing(signing, verification)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ring
+	}
+
+	before, err := newTestManager(t, db, ring(oldPrivate)).Generate(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    *KeyRing
+		wantErr bool
+	}{
+		{"same key", ring(oldPrivate), false},
+		{"rotated with old public key", ring(newPrivate, oldPublic), false},
+		{"rotated with old private key", ring(newPrivate, oldPrivate), false},
+		{"old key dropped", ring(newPrivate), true},
+		{"hmac", NewHMACKeyRing(testSecret), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			manager := newTestManager(t, db, tc.keys)
+			if _, err := manager.Verify(before.AccessToken); (err != nil) != tc.wantErr {
+				t.Errorf("Verify(token signed with old key) = %v, want error %v", err, tc.wantErr)
+			}
+
+			token, err := manager.Generate(userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := manager.Verify(token.AccessToken); err != nil {
+				t.Errorf("Verify(new token) = %v", err)
+			}
+		})
+	}
+}
+
+// A token must be signed with the algorithm of the key its kid names, so the
+// public key cannot be used as an HMAC secret.
+func TestAlgorithmConfusion(t *testing.T) {
+	db := migrationstest.Open(t)
+	userID := migrationstest.CreateUser(t, db, "alice").PublicId
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := LoadKeyRing(writeKey(t, t.TempDir(), "signing", "RSA PRIVATE KEY", rsaKey), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := newTestManager(t, db, ring)
+	kid := ring.SigningKey().Kid
+
+	publicDER, err := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    interface{}
+	}{
+		{"hmac with public key", jwt.SigningMethodHS256, kid, publicPEM},
+		{"unsigned", jwt.SigningMethodNone, kid, jwt.UnsafeAllowNoneSignatureType},
+		{"unknown kid", jwt.SigningMethodRS256, "unknown", rsaKey},
+		{"no kid", jwt.SigningMethodRS256, "", rsaKey},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(tc.method, UserClaims{UserId: userID, TokenType: AccessTokenType})
+			if tc.kid != "" {
+				token.Header["kid"] = tc.kid
+			}
+			signed, err := token.SignedString(tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := manager.Verify(signed); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := LoadKeyRing(writeKey(t, dir, "rsa", "RSA PRIVATE KEY", rsaKey), []string{writeKey(t, dir, "ec", "PUBLIC KEY", ecKey.Public())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		keys     *KeyRing
+		wantKeys map[string]string
+	}{
+		{"hmac keys are secret", NewHMACKeyRing(testSecret), map[string]string{}},
+		{"signing and verification keys", ring, map[string]string{"RSA": "RS256", "EC": "ES256"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			set := tc.keys.JWKS()
+			if len(set.Keys) != len(tc.wantKeys) {
+				t.Fatalf("%d keys, want %d", len(set.Keys), len(tc.wantKeys))
+			}
+			for i, jwk := range set.Keys {
+				if jwk.Alg != tc.wantKeys[jwk.Kty] || jwk.Use != "sig" {
+					t.Errorf("key %s has alg %s, use %s", jwk.Kty, jwk.Alg, jwk.Use)
+				}
+				if _, err := tc.keys.VerificationKey(jwk.Kid); err != nil {
+					t.Errorf("kid %s: %v", jwk.Kid, err)
+				}
+				if i > 0 && set.Keys[i-1].Kid > jwk.Kid {
+					t.Error("keys are not sorted by kid")
+				}
+				if jwk.Kty == "RSA" && (jwk.N == "" || jwk.E != "AQAB") {
+					t.Errorf("n = %q, e = %q", jwk.N, jwk.E)
+				}
+				if jwk.Kty == "EC" && (jwk.Crv != "P-256" || len(jwk.X) != 43 || len(jwk.Y) != 43) {
+					t.Errorf("crv = %q, x = %q, y = %q", jwk.Crv, jwk.X, jwk.Y)
+				}
+			}
+		})
+	}
+}
@@ -2,27 +2,104 @@ package manager
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	jwt "github.com/dgrijalva/jwt-go"
+	jwt "github.com/golang-jwt/jwt/v4"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/metadata"
 )
 
+const (
+	AccessTokenType       = "access"
+	RefreshTokenType      = "refresh"
+	MfaChallengeTokenType = "mfa_challenge"
+
+	mfaChallengeDuration = 5 * time.Minute
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	ErrTokenRevoked        = errors.New("token revoked")
+	ErrInvalidMfaChallenge = errors.New("invalid mfa challenge")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrTokenNotOwned       = errors.New("token does not belong to user")
+	ErrTokenNotRevocable   = errors.New("token cannot be revoked")
+)
+
 type JWTManager struct {
-	secretKey     string
-	tokenDuration time.Duration
+	keys *KeyRing
+
+	mu                   sync.RWMutex
+	tokenDuration        time.Duration
+	refreshTokenDuration time.Duration
+
+	refreshTokens RefreshTokenStore
+	revocations   RevocationStore
+	authorities   AuthorityResolver
+	logger        *logrus.Logger
 }
 
 type UserClaims struct {
 	jwt.StandardClaims
-	UserId string `json:"user_id"`
+	// IssuedAtMs is the issue time in milliseconds. iat has whole seconds,
+	// too coarse to tell whether a token was issued before or after the
+	// sessions of its user were revoked within the same second.
+	IssuedAtMs  int64    `json:"iat_ms,omitempty"`
+	UserId      string   `json:"user_id"`
+	TokenType   string   `json:"token_type,omitempty"`
+	FamilyId    string   `json:"family_id,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// issuedAt returns the issue time at the best precision the token carries.
+func (claims *UserClaims) issuedAt() time.Time {
+	if claims.IssuedAtMs != 0 {
+		return time.UnixMilli(claims.IssuedAtMs)
+	}
+
+	return time.Unix(claims.IssuedAt, 0)
+}
+
+func (claims *UserClaims) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, granted := range claims.Roles {
+			if role == granted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (claims *UserClaims) HasPermissions(permissions ...string) bool {
+	for _, permission := range permissions {
+		granted := false
+		for _, p := range claims.Permissions {
+			if p == permission {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
 }
 
 type JWTToken struct {
-	AccessToken string
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
 }
 
 func (jw *JWTToken) GetAccessToken() string {
@@ -32,53 +109,330 @@ func (jw *JWTToken) GetAccessToken() string {
 	return jw.AccessToken
 }
 
-func NewJWTManager(secretKey string, tokenDuration time.Duration, logger *logrus.Logger) *JWTManager {
-	return &JWTManager{secretKey, tokenDuration}
+func (jw *JWTToken) GetRefreshToken() string {
+	if jw == nil {
+		return ""
+	}
+	return jw.RefreshToken
+}
+
+func (jw *JWTToken) GetExpiresIn() int64 {
+	if jw == nil {
+		return 0
+	}
+	return jw.ExpiresIn
+}
+
+func NewJWTManager(keys *KeyRing, tokenDuration time.Duration, refreshTokenDuration time.Duration, refreshTokens RefreshTokenStore, revocations RevocationStore, authorities AuthorityResolver, logger *logrus.Logger) *JWTManager {
+	return &JWTManager{
+		keys:                 keys,
+		tokenDuration:        tokenDuration,
+		refreshTokenDuration: refreshTokenDuration,
+		refreshTokens:        refreshTokens,
+		revocations:          revocations,
+		authorities:          authorities,
+		logger:               logger,
+	}
 }
 
+// SetDurations changes the lifetime of tokens issued from now on.
+func (manager *JWTManager) SetDurations(tokenDuration time.Duration, refreshTokenDuration time.Duration) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	manager.tokenDuration = tokenDuration
+	manager.refreshTokenDuration = refreshTokenDuration
+}
+
+func (manager *JWTManager) durations() (time.Duration, time.Duration) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	return manager.tokenDuration, manager.refreshTokenDuration
+}
+
+// Generate issues an access token together with a refresh token that starts
+// a new token family.
 func (manager *JWTManager) Generate(userID string) (*JWTToken, error) {
-	var err error
+	familyID, err := newTokenId()
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.generatePair(userID, familyID)
+}
+
+// Refresh rotates a refresh token: the presented token is marked as used and a
+// new access/refresh pair in the same family is returned. Presenting a token
+// that was already rotated revokes the whole family.
+func (manager *JWTManager) Refresh(refreshToken string) (*JWTToken, error) {
+	claims, err := manager.parse(refreshToken)
+	if err != nil || claims.TokenType != RefreshTokenType || claims.Id == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := manager.refreshTokens.Find(claims.Id)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.UserId != claims.UserId || stored.FamilyId != claims.FamilyId {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, ErrRefreshTokenRevoked
+	}
+
+	now := time.Now()
+	if stored.UsedAt != nil {
+		return nil, manager.revokeReusedFamily(stored.FamilyId, stored.UserId, now)
+	}
+
+	marked, err := manager.refreshTokens.MarkUsed(stored.Jti, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// Lost a race against another request presenting the same token.
+		return nil, manager.revokeReusedFamily(stored.FamilyId, stored.UserId, now)
+	}
+
+	return manager.generatePair(stored.UserId, stored.FamilyId)
+}
+
+func (manager *JWTManager) revokeReusedFamily(familyID string, userID string, now time.Time) error {
+	manager.logger.WithFields(logrus.Fields{
+		"family_id": familyID,
+		"user_id":   userID,
+	}).Warn("Refresh token reuse detected, revoking token family")
+
+	if err := manager.refreshTokens.RevokeFamily(familyID, now); err != nil {
+		return err
+	}
+
+	return ErrRefreshTokenReused
+}
+
+func (manager *JWTManager) generatePair(userID string, familyID string) (*JWTToken, error) {
 	now := time.Now()
+	tokenDuration, refreshTokenDuration := manager.durations()
+
+	authorities, err := manager.authorities.Authorities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessJti, err := newTokenId()
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := manager.sign(UserClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        accessJti,
+			ExpiresAt: now.Add(tokenDuration).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		IssuedAtMs:  now.UnixMilli(),
+		UserId:      userID,
+		TokenType:   AccessTokenType,
+		Roles:       authorities.Roles,
+		Permissions: authorities.Permissions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := newTokenId()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(refreshTokenDuration)
+	refreshToken, err := manager.sign(UserClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		UserId:    userID,
+		TokenType: RefreshTokenType,
+		FamilyId:  familyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := manager.refreshTokens.Create(jti, familyID, userID, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &JWTToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(tokenDuration.Seconds()),
+	}, nil
+}
 
-	claims := UserClaims{
+// GenerateMfaChallenge issues a short lived token proving that the password
+// step of a login succeeded. It cannot be used as an access token and is
+// exchanged for one once the second factor is verified.
+func (manager *JWTManager) GenerateMfaChallenge(userID string) (string, error) {
+	jti, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return manager.sign(UserClaims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: now.Add(manager.tokenDuration).Unix(),
+			Id:        jti,
+			ExpiresAt: now.Add(mfaChallengeDuration).Unix(),
 			IssuedAt:  now.Unix(),
 		},
-		UserId: userID,
+		IssuedAtMs: now.UnixMilli(),
+		UserId:     userID,
+		TokenType:  MfaChallengeTokenType,
+	})
+}
+
+// VerifyMfaChallenge validates a token returned by GenerateMfaChallenge.
+func (manager *JWTManager) VerifyMfaChallenge(challenge string) (*UserClaims, error) {
+	claims, err := manager.parse(challenge)
+	if err != nil || claims.TokenType != MfaChallengeTokenType {
+		return nil, ErrInvalidMfaChallenge
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(manager.secretKey))
+	revoked, err := manager.revocations.IsRevoked(claims.Id, claims.UserId, claims.issuedAt())
 	if err != nil {
 		return nil, err
 	}
+	if revoked {
+		return nil, ErrInvalidMfaChallenge
+	}
 
-	return &JWTToken{AccessToken: tokenString}, nil
+	return claims, nil
+}
 
+func (manager *JWTManager) sign(claims UserClaims) (string, error) {
+	key := manager.keys.SigningKey()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.Kid != "" {
+		token.Header["kid"] = key.Kid
+	}
+
+	return token.SignedString(key.Private)
+}
+
+// JWKS returns the public keys that verify tokens issued by this manager.
+func (manager *JWTManager) JWKS() JSONWebKeySet {
+	return manager.keys.JWKS()
 }
 
 func (manager *JWTManager) Verify(accessToken string) (*UserClaims, error) {
+	claims, err := manager.parse(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before refresh tokens existed carry no type.
+	if claims.TokenType != "" && claims.TokenType != AccessTokenType {
+		return nil, fmt.Errorf("%w: unexpected token type", ErrInvalidToken)
+	}
+
+	revoked, err := manager.revocations.IsRevoked(claims.Id, claims.UserId, claims.issuedAt())
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// Revoke invalidates a token before it expires. Access tokens are added to the
+// revocation store, refresh tokens revoke their whole family. When userID is
+// not empty the token must belong to that user.
+func (manager *JWTManager) Revoke(tokenString string, userID string) error {
+	claims, err := manager.parse(tokenString)
+	if err != nil {
+		return err
+	}
+
+	if userID != "" && claims.UserId != userID {
+		return ErrTokenNotOwned
+	}
+
+	return manager.RevokeClaims(claims)
+}
+
+// RevokeUserSessions ends every session of the user: its refresh token
+// families are revoked and the access tokens and MFA challenges issued so far
+// are rejected. Tokens generated afterwards are valid.
+func (manager *JWTManager) RevokeUserSessions(userID string) error {
+	now := time.Now()
+	if err := manager.refreshTokens.RevokeUser(userID, now); err != nil {
+		return err
+	}
+
+	tokenDuration, _ := manager.durations()
+	if tokenDuration < mfaChallengeDuration {
+		tokenDuration = mfaChallengeDuration
+	}
+
+	if err := manager.revocations.RevokeUser(userID, now, now.Add(tokenDuration)); err != nil {
+		return err
+	}
+
+	// Tokens issued in the millisecond of the revocation are rejected, wait
+	// for the next one so the tokens generated afterwards are valid.
+	time.Sleep(time.Until(now.Truncate(time.Millisecond).Add(time.Millisecond)))
+
+	return nil
+}
+
+// RevokeClaims revokes the token described by already verified claims.
+func (manager *JWTManager) RevokeClaims(claims *UserClaims) error {
+	if claims.TokenType == RefreshTokenType {
+		return manager.refreshTokens.RevokeFamily(claims.FamilyId, time.Now())
+	}
+
+	if claims.Id == "" {
+		return ErrTokenNotRevocable
+	}
+
+	return manager.revocations.Revoke(claims.Id, claims.UserId, time.Unix(claims.ExpiresAt, 0))
+}
+
+func (manager *JWTManager) parse(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(
-		accessToken,
+		tokenString,
 		&UserClaims{},
 		func(token *jwt.Token) (interface{}, error) {
-			_, ok := token.Method.(*jwt.SigningMethodHMAC)
-			if !ok {
+			kid, _ := token.Header["kid"].(string)
+			key, err := manager.keys.VerificationKey(kid)
+			if err != nil {
+				return nil, err
+			}
+
+			if token.Method.Alg() != key.Method.Alg() {
 				return nil, errors.New("unexpected token signing method")
 			}
 
-			return []byte(manager.secretKey), nil
+			return key.Public, nil
 		},
 	)
 
 	if err != nil {
-		return nil, errors.New("invalid token: " + err.Error())
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
 	claims, ok := token.Claims.(*UserClaims)
 	if !ok {
-		return nil, errors.New("invalid token claims")
+		return nil, fmt.Errorf("%w: unexpected claims", ErrInvalidToken)
 	}
 
 	return claims, nil
@@ -108,3 +462,11 @@ func (manager *JWTManager) GetAccessToken(ctx context.Context) (*string, error)
 
 	return &accessToken, nil
 }
+
+func newTokenId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,164 @@
+package manager
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const testSecret = "test-secret"
+
+// newTestManager returns a manager signing with keys, storing its tokens in db.
+func newTestManager(t *testing.T, db *gorm.DB, keys *KeyRing) *JWTManager {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return NewJWTManager(keys, time.Minute, time.Hour, NewGormRefreshTokenStore(db), NewGormRevocationStore(db, time.Minute), NewGormAuthorityResolver(db), logger)
+}
+
+// Every refresh token can be rotated once. Presenting a rotated token again
+// revokes its family, including the tokens issued after it, but leaves other
+// families alone.
+func TestRefresh(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+	userID := migrationstest.CreateUser(t, db, "alice").PublicId
+
+	first, err := manager.Generate(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := manager.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := manager.Generate(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"access token", first.AccessToken, ErrInvalidRefreshToken},
+		{"garbage", "not a token", ErrInvalidRefreshToken},
+		{"reused token", first.RefreshToken, ErrRefreshTokenReused},
+		{"successor of reused token", second.RefreshToken, ErrRefreshTokenRevoked},
+		{"other family", other.RefreshToken, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := manager.Refresh(tc.token)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("Refresh = %v, want %v", err, tc.want)
+			}
+			if err != nil {
+				return
+			}
+
+			claims, err := manager.Verify(token.AccessToken)
+			if err != nil {
+				t.Fatalf("Verify(refreshed access token) = %v", err)
+			}
+			if claims.UserId != userID {
+				t.Errorf("user_id = %s, want %s", claims.UserId, userID)
+			}
+		})
+	}
+}
+
+// Refreshing picks up the roles granted since the last rotation.
+func TestRefreshAuthorities(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+	userID := migrationstest.CreateUser(t, db, "alice").PublicId
+
+	token, err := manager.Generate(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role := &models.Role{Name: "support", Permissions: []models.Permission{{Name: "users:read"}}}
+	if err := db.Create(role).Error; err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{}
+	if err := db.Where("public_id = ?", userID).First(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(user).Association("Roles").Append(role); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = manager.Refresh(token.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := manager.Verify(token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.HasAnyRole("support") || !claims.HasPermissions("users:read") {
+		t.Errorf("roles = %v, permissions = %v", claims.Roles, claims.Permissions)
+	}
+}
+
+func TestVerifyTokenType(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+	userID := migrationstest.CreateUser(t, db, "alice").PublicId
+
+	token, err := manager.Generate(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := manager.GenerateMfaChallenge(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := manager.sign(UserClaims{
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Minute).Unix()},
+		UserId:         userID,
+		TokenType:      AccessTokenType,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := newTestManager(t, db, NewHMACKeyRing("other-secret")).Generate(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"access token", token.AccessToken, nil},
+		{"refresh token", token.RefreshToken, ErrInvalidToken},
+		{"mfa challenge", challenge, ErrInvalidToken},
+		{"expired", expired, ErrInvalidToken},
+		{"signed with another key", foreign.AccessToken, ErrInvalidToken},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := manager.Verify(tc.token); !errors.Is(err, tc.want) {
+				t.Errorf("Verify = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
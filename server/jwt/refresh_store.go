@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"go-auth/server/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenStore persists issued refresh tokens and their families.
+type RefreshTokenStore interface {
+	Create(jti string, familyID string, userID string, expiresAt time.Time) error
+	Find(jti string) (*models.RefreshToken, error)
+	// MarkUsed flags the token as rotated. It reports false when the token
+	// had already been used, which callers must treat as reuse.
+	MarkUsed(jti string, usedAt time.Time) (bool, error)
+	RevokeFamily(familyID string, revokedAt time.Time) error
+	RevokeUser(userID string, revokedAt time.Time) error
+}
+
+type gormRefreshTokenStore struct {
+	db *gorm.DB
+}
+
+func NewGormRefreshTokenStore(db *gorm.DB) RefreshTokenStore {
+	return &gormRefreshTokenStore{db: db}
+}
+
+func (s *gormRefreshTokenStore) Create(jti string, familyID string, userID string, expiresAt time.Time) error {
+	return s.db.Create(&models.RefreshToken{
+		Jti:       jti,
+		FamilyId:  familyID,
+		UserId:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (s *gormRefreshTokenStore) Find(jti string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	if err := s.db.Where("jti = ?", jti).First(token).Error; err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (s *gormRefreshTokenStore) MarkUsed(jti string, usedAt time.Time) (bool, error) {
+	result := s.db.Model(&models.RefreshToken{}).
+		Where("jti = ? AND used_at IS NULL", jti).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (s *gormRefreshTokenStore) RevokeFamily(familyID string, revokedAt time.Time) error {
+	return s.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (s *gormRefreshTokenStore) RevokeUser(userID string, revokedAt time.Time) error {
+	return s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
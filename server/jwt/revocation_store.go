@@ -0,0 +1,171 @@
+package manager
+
+import (
+	"go-auth/server/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore keeps track of access tokens revoked before their expiry,
+// one by one or all tokens of a user issued before a point in time.
+type RevocationStore interface {
+	Revoke(jti string, userID string, expiresAt time.Time) error
+	// RevokeUser rejects the tokens of userID issued up to and including
+	// the millisecond of before. The record is needed until expiresAt, when
+	// the last of those tokens expires.
+	RevokeUser(userID string, before time.Time, expiresAt time.Time) error
+	// IsRevoked reports whether the token with the given jti, issued to
+	// userID at issuedAt, was revoked. jti may be empty.
+	IsRevoked(jti string, userID string, issuedAt time.Time) (bool, error)
+}
+
+type revokedUser struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+// gormRevocationStore persists revocations in the database and answers
+// lookups from an in-memory copy. Revocations made by this process are visible
+// immediately, revocations made by other instances once the copy is reloaded.
+type gormRevocationStore struct {
+	db           *gorm.DB
+	syncInterval time.Duration
+
+	// syncMu lets one caller reload the copy while the others wait for it.
+	syncMu sync.Mutex
+
+	mu       sync.RWMutex
+	revoked  map[string]time.Time
+	users    map[string]revokedUser
+	lastSync time.Time
+}
+
+func NewGormRevocationStore(db *gorm.DB, syncInterval time.Duration) RevocationStore {
+	return &gormRevocationStore{
+		db:           db,
+		syncInterval: syncInterval,
+		revoked:      map[string]time.Time{},
+		users:        map[string]revokedUser{},
+	}
+}
+
+func (s *gormRevocationStore) Revoke(jti string, userID string, expiresAt time.Time) error {
+	err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		Jti:       jti,
+		UserId:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.revoked[jti] = expiresAt
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *gormRevocationStore) RevokeUser(userID string, before time.Time, expiresAt time.Time) error {
+	// Tokens carry their issue time in milliseconds, the precision every
+	// supported database stores. A token issued in the same millisecond as
+	// the revocation is rejected, whether it came before or after it.
+	before = before.Truncate(time.Millisecond)
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at", "updated_at"}),
+	}).Create(&models.RevokedSession{
+		UserId:        userID,
+		RevokedBefore: before,
+		ExpiresAt:     expiresAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = revokedUser{before: before, expiresAt: expiresAt}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *gormRevocationStore) IsRevoked(jti string, userID string, issuedAt time.Time) (bool, error) {
+	if err := s.syncIfStale(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if user, ok := s.users[userID]; ok && !issuedAt.After(user.before) {
+		return true, nil
+	}
+
+	_, ok := s.revoked[jti]
+	return ok && jti != "", nil
+}
+
+func (s *gormRevocationStore) syncIfStale() error {
+	if !s.stale() {
+		return nil
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	// Another caller may have reloaded while this one waited.
+	if !s.stale() {
+		return nil
+	}
+
+	now := time.Now()
+	var tokens []models.RevokedToken
+	if err := s.db.Where("expires_at > ?", now).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	var sessions []models.RevokedSession
+	if err := s.db.Where("expires_at > ?", now).Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	revoked := make(map[string]time.Time, len(tokens))
+	for _, token := range tokens {
+		revoked[token.Jti] = token.ExpiresAt
+	}
+
+	users := make(map[string]revokedUser, len(sessions))
+	for _, session := range sessions {
+		users[session.UserId] = revokedUser{before: session.RevokedBefore, expiresAt: session.ExpiresAt}
+	}
+
+	s.mu.Lock()
+	// Keep local revocations that raced with the reload.
+	for jti, expiresAt := range s.revoked {
+		if _, ok := revoked[jti]; !ok && expiresAt.After(now) {
+			revoked[jti] = expiresAt
+		}
+	}
+	for userID, user := range s.users {
+		if synced, ok := users[userID]; (!ok || synced.before.Before(user.before)) && user.expiresAt.After(now) {
+			users[userID] = user
+		}
+	}
+	s.revoked = revoked
+	s.users = users
+	s.lastSync = now
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *gormRevocationStore) stale() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return time.Since(s.lastSync) >= s.syncInterval
+}
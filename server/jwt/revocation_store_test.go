@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-auth/server/migrations/migrationstest"
+
+	"gorm.io/gorm"
+)
+
+func TestRevoke(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+	alice := migrationstest.CreateUser(t, db, "alice").PublicId
+	bob := migrationstest.CreateUser(t, db, "bob").PublicId
+
+	aliceToken, err := manager.Generate(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobToken, err := manager.Generate(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		userID    string
+		want      error
+		verify    string
+		wantAfter error
+	}{
+		{"token of another user", bobToken.AccessToken, alice, ErrTokenNotOwned, bobToken.AccessToken, nil},
+		{"garbage", "not a token", alice, ErrInvalidToken, aliceToken.AccessToken, nil},
+		{"access token", aliceToken.AccessToken, alice, nil, aliceToken.AccessToken, ErrTokenRevoked},
+		{"access token again", aliceToken.AccessToken, alice, nil, aliceToken.AccessToken, ErrTokenRevoked},
+		{"refresh token by admin", bobToken.RefreshToken, "", nil, bobToken.AccessToken, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := manager.Revoke(tc.token, tc.userID); !errors.Is(err, tc.want) {
+				t.Fatalf("Revoke = %v, want %v", err, tc.want)
+			}
+			if _, err := manager.Verify(tc.verify); !errors.Is(err, tc.wantAfter) {
+				t.Errorf("Verify after revoke = %v, want %v", err, tc.wantAfter)
+			}
+		})
+	}
+
+	if _, err := manager.Refresh(bobToken.RefreshToken); !errors.Is(err, ErrRefreshTokenRevoked) {
+		t.Errorf("Refresh of revoked family = %v, want %v", err, ErrRefreshTokenRevoked)
+	}
+}
+
+// Ending the sessions of a user rejects what was issued before, also within
+// the same second, not what is issued afterwards, and leaves other users
+// alone.
+func TestRevokeUserSessions(t *testing.T) {
+	db := migrationstest.Open(t)
+	manager := newTestManager(t, db, NewHMACKeyRing(testSecret))
+	alice := migrationstest.CreateUser(t, db, "alice").PublicId
+	bob := migrationstest.CreateUser(t, db, "bob").PublicId
+
+	before, err := manager.Generate(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := manager.GenerateMfaChallenge(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := manager.Generate(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.RevokeUserSessions(alice); err != nil {
+		t.Fatal(err)
+	}
+	after, err := manager.Generate(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		check func() error
+		want  error
+	}{
+		{"access token issued before", func() error { _, err := manager.Verify(before.AccessToken); return err }, ErrTokenRevoked},
+		{"refresh token issued before", func() error { _, err := manager.Refresh(before.RefreshToken); return err }, ErrRefreshTokenRevoked},
+		{"mfa challenge issued before", func() error { _, err := manager.VerifyMfaChallenge(challenge); return err }, ErrInvalidMfaChallenge},
+		{"access token issued after", func() error { _, err := manager.Verify(after.AccessToken); return err }, nil},
+		{"refresh token issued after", func() error { _, err := manager.Refresh(after.RefreshToken); return err }, nil},
+		{"other user", func() error { _, err := manager.Verify(other.AccessToken); return err }, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.check(); !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// A store sees the revocations of other instances once it reloads.
+func TestRevocationStoreSync(t *testing.T) {
+	db := migrationstest.Open(t)
+	local := NewGormRevocationStore(db, time.Hour)
+	remote := NewGormRevocationStore(db, 0)
+	issuedAt := time.Now().Add(-time.Minute)
+	expiresAt := time.Now().Add(time.Hour)
+
+	if err := local.Revoke("revoked", "alice", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.Revoke("expired", "alice", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	revokedAt := time.Now()
+	if err := local.RevokeUser("bob", revokedAt, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{"revoked token", "revoked", "alice", issuedAt, true},
+		{"expired revocation", "expired", "alice", issuedAt, false},
+		{"other token", "other", "alice", issuedAt, false},
+		{"token without jti", "", "alice", issuedAt, false},
+		{"token of revoked user", "", "bob", issuedAt, true},
+		{"token of revoked user issued in the same millisecond", "", "bob", revokedAt.Truncate(time.Millisecond), true},
+		{"token of revoked user issued a millisecond later", "", "bob", revokedAt.Truncate(time.Millisecond).Add(time.Millisecond), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked, err := remote.IsRevoked(tc.jti, tc.userID, tc.issuedAt)
+			if err != nil || revoked != tc.want {
+				t.Errorf("IsRevoked = %v, %v, want %v", revoked, err, tc.want)
+			}
+		})
+	}
+}
+
+// Concurrent lookups on a stale copy reload it once.
+func TestRevocationStoreSyncOnce(t *testing.T) {
+	db := migrationstest.Open(t)
+	var queries atomic.Int32
+	err := db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) {
+		queries.Add(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewGormRevocationStore(db, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.IsRevoked("jti", "alice", time.Now()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One query for the revoked tokens, one for the revoked sessions.
+	if n := queries.Load(); n != 2 {
+		t.Errorf("%d queries, want 2", n)
+	}
+}
@@ -0,0 +1,146 @@
+// Package actiontoken issues signed single-use tokens that authorize one
+// action for a subject, e.g. verifying an email address or resetting a
+// password.
+//
+// A token is "<nonce>.<signature>" where the signature is an HMAC-SHA256 of the
+// purpose and nonce. Tokens of another purpose or with a forged signature are
+// rejected without a database lookup; the stored hash makes them single use and
+// carries the subject and expiry.
+package actiontoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-auth/server/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+type Manager struct {
+	db  *gorm.DB
+	key []byte
+}
+
+func NewManager(db *gorm.DB, key []byte) *Manager {
+	return &Manager{db: db, key: key}
+}
+
+// WithTx returns a manager running its queries in tx.
+func (m *Manager) WithTx(tx *gorm.DB) *Manager {
+	return &Manager{db: tx, key: m.key}
+}
+
+// Issue creates a token for subject, acting on the user with the internal id
+// userID, valid for ttl.
+func (m *Manager) Issue(purpose string, userID uint, subject string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	token := nonce + "." + m.sign(purpose, nonce)
+
+	err := m.db.Create(&models.ActionToken{
+		Purpose:   purpose,
+		Subject:   subject,
+		UserId:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}).Error
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Peek returns the subject of a usable token of purpose without consuming it,
+// e.g. to reject invalid tokens before expensive work. Consume must still be
+// called, the token may be used up in between.
+func (m *Manager) Peek(purpose string, token string) (string, error) {
+	stored, err := m.find(purpose, token)
+	if err != nil {
+		return "", err
+	}
+
+	if stored.ConsumedAt != nil || !stored.ExpiresAt.After(time.Now()) {
+		return "", ErrInvalidToken
+	}
+
+	return stored.Subject, nil
+}
+
+// Consume marks a token of purpose as used and returns its subject. Every
+// token is accepted once, expired and unknown tokens return ErrInvalidToken.
+func (m *Manager) Consume(purpose string, token string) (string, error) {
+	stored, err := m.find(purpose, token)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	result := m.db.Model(&models.ActionToken{}).
+		Where("id = ? AND consumed_at IS NULL AND expires_at > ?", stored.ID, now).
+		Update("consumed_at", now)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", ErrInvalidToken
+	}
+
+	return stored.Subject, nil
+}
+
+// find returns the stored token of purpose after checking its signature.
+func (m *Manager) find(purpose string, token string) (*models.ActionToken, error) {
+	nonce, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(m.sign(purpose, nonce))) {
+		return nil, ErrInvalidToken
+	}
+
+	stored := &models.ActionToken{}
+	err := m.db.Where("token_hash = ? AND purpose = ?", hashToken(token), purpose).First(stored).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// RevokeAll consumes every outstanding token of purpose issued to the user,
+// whatever its subject.
+func (m *Manager) RevokeAll(purpose string, userID uint) error {
+	return m.db.Model(&models.ActionToken{}).
+		Where("purpose = ? AND user_id = ? AND consumed_at IS NULL", purpose, userID).
+		Update("consumed_at", time.Now()).Error
+}
+
+// DeleteAll removes every token issued to the user regardless of purpose and
+// subject.
+func (m *Manager) DeleteAll(userID uint) error {
+	return m.db.Unscoped().Where("user_id = ?", userID).Delete(&models.ActionToken{}).Error
+}
+
+func (m *Manager) sign(purpose string, nonce string) string {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(purpose + "." + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
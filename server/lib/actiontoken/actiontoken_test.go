@@ -0,0 +1,143 @@
+package actiontoken
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-auth/server/database/databasetest"
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+
+	"gorm.io/gorm"
+)
+
+func TestConsume(t *testing.T) {
+	db := migrationstest.Open(t)
+	m := NewManager(db, []byte(databasetest.AppKey))
+
+	const alice, bob = 1, 2
+	issue := func(purpose string, userID uint, subject string, ttl time.Duration) string {
+		token, err := m.Issue(purpose, userID, subject, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	token := issue("verify_email", alice, "1:alice@example.com", time.Hour)
+	expired := issue("verify_email", alice, "1:alice@example.com", -time.Second)
+	revoked := issue("reset_password", alice, "1", time.Hour)
+	revokedOtherSubject := issue("reset_password", alice, "1:old", time.Hour)
+	kept := issue("reset_password", bob, "2", time.Hour)
+	if err := m.RevokeAll("reset_password", alice); err != nil {
+		t.Fatal(err)
+	}
+	nonce, _, _ := strings.Cut(token, ".")
+	forged := nonce + "." + NewManager(db, []byte("other key")).sign("verify_email", nonce)
+
+	tests := []struct {
+		name        string
+		purpose     string
+		token       string
+		wantSubject string
+		wantErr     error
+	}{
+		{"other purpose", "reset_password", token, "", ErrInvalidToken},
+		{"forged signature", "verify_email", forged, "", ErrInvalidToken},
+		{"no signature", "verify_email", nonce, "", ErrInvalidToken},
+		{"expired", "verify_email", expired, "", ErrInvalidToken},
+		{"revoked", "reset_password", revoked, "", ErrInvalidToken},
+		{"revoked with another subject", "reset_password", revokedOtherSubject, "", ErrInvalidToken},
+		{"revocation of another user", "reset_password", kept, "2", nil},
+		{"revocation of another purpose", "verify_email", token, "1:alice@example.com", nil},
+		{"used", "verify_email", token, "", ErrInvalidToken},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			peeked, peekErr := m.Peek(tc.purpose, tc.token)
+			subject, err := m.Consume(tc.purpose, tc.token)
+			if !errors.Is(err, tc.wantErr) || subject != tc.wantSubject {
+				t.Errorf("Consume = %q, %v, want %q, %v", subject, err, tc.wantSubject, tc.wantErr)
+			}
+			// Peek answers like Consume without using the token up.
+			if !errors.Is(peekErr, tc.wantErr) || peeked != tc.wantSubject {
+				t.Errorf("Peek = %q, %v, want %q, %v", peeked, peekErr, tc.wantSubject, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	db := migrationstest.Open(t)
+	m := NewManager(db, []byte(databasetest.AppKey))
+
+	// Subjects as the API issues them, the user id with or without an
+	// email address.
+	subjects := map[string]string{"verify_email": ":user@example.com", "reset_password": ""}
+	tokens := map[string]string{}
+	for _, purpose := range []string{"verify_email", "reset_password"} {
+		for _, userID := range []uint{1, 2} {
+			subject := strconv.FormatUint(uint64(userID), 10) + subjects[purpose]
+			token, err := m.Issue(purpose, userID, subject, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tokens[purpose+" "+subject] = token
+		}
+	}
+
+	if err := m.DeleteAll(1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		purpose string
+		subject string
+		wantErr error
+	}{
+		{"verify_email", "1:user@example.com", ErrInvalidToken},
+		{"reset_password", "1", ErrInvalidToken},
+		{"verify_email", "2:user@example.com", nil},
+		{"reset_password", "2", nil},
+	}
+	for _, tc := range tests {
+		if _, err := m.Peek(tc.purpose, tokens[tc.purpose+" "+tc.subject]); !errors.Is(err, tc.wantErr) {
+			t.Errorf("Peek of the %s token of %s = %v, want %v", tc.purpose, tc.subject, err, tc.wantErr)
+		}
+	}
+
+	var left int64
+	if err := db.Unscoped().Model(&models.ActionToken{}).Where("subject LIKE ?", "1%").Count(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left != 0 {
+		t.Errorf("%d tokens of the deleted user left", left)
+	}
+}
+
+// Tokens that were not committed together with the transaction they were
+// issued in do not exist.
+func TestWithTx(t *testing.T) {
+	db := migrationstest.Open(t)
+	m := NewManager(db, []byte(databasetest.AppKey))
+
+	var token string
+	errRollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if token, err = m.WithTx(tx).Issue("verify_email", 1, "1", time.Hour); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Consume("verify_email", token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Consume of a rolled back token = %v, want %v", err, ErrInvalidToken)
+	}
+}
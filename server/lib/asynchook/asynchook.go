@@ -0,0 +1,51 @@
+// Package asynchook fires logrus hooks without blocking the logging goroutine.
+package asynchook
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Hook fires the wrapped hook in the background. Unlike the async hook of
+// elogrus it keeps track of the entries in flight, so they can be sent before
+// the process exits, see Flush.
+type Hook struct {
+	hook     logrus.Hook
+	inFlight sync.WaitGroup
+}
+
+func New(hook logrus.Hook) *Hook {
+	return &Hook{hook: hook}
+}
+
+func (h *Hook) Levels() []logrus.Level {
+	return h.hook.Levels()
+}
+
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	h.inFlight.Add(1)
+	go func() {
+		defer h.inFlight.Done()
+		h.hook.Fire(entry)
+	}()
+
+	return nil
+}
+
+// Flush waits until the entries fired so far are handled or ctx is done.
+func (h *Hook) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
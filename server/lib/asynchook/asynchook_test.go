@@ -0,0 +1,93 @@
+package asynchook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// blockingHook records the entries it fires, each one after release is
+// closed.
+type blockingHook struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	entries []string
+}
+
+func (h *blockingHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.ErrorLevel}
+}
+
+func (h *blockingHook) Fire(entry *logrus.Entry) error {
+	<-h.release
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry.Message)
+	return nil
+}
+
+func (h *blockingHook) fired() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+func TestHook(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   int
+		release   bool
+		wantErr   error
+		wantFired int
+	}{
+		{"nothing in flight", 0, false, nil, 0},
+		{"flushes entries in flight", 3, true, nil, 3},
+		{"gives up at the deadline", 2, false, context.DeadlineExceeded, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inner := &blockingHook{release: make(chan struct{})}
+			defer close(inner.release)
+			hook := New(inner)
+
+			if levels := hook.Levels(); len(levels) != 1 || levels[0] != logrus.ErrorLevel {
+				t.Errorf("levels %v, want the ones of the wrapped hook", levels)
+			}
+
+			start := time.Now()
+			for i := 0; i < tc.entries; i++ {
+				if err := hook.Fire(&logrus.Entry{Message: "failed"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Fire blocked for %s", elapsed)
+			}
+
+			if tc.release {
+				entries := tc.entries
+				go func() {
+					for i := 0; i < entries; i++ {
+						inner.release <- struct{}{}
+					}
+				}()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if err := hook.Flush(ctx); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Flush = %v, want %v", err, tc.wantErr)
+			}
+			if fired := inner.fired(); fired != tc.wantFired {
+				t.Errorf("%d entries fired, want %d", fired, tc.wantFired)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Package cors answers cross-origin requests of browsers.
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy describes which cross-origin requests browsers may make.
+//
+// AllowedOrigins holds origins like https://app.example.com, "*" for every
+// origin or wildcard subdomains like https://*.example.com. With
+// AllowCredentials the matching origin is echoed instead of "*", as browsers
+// refuse credentialed responses allowing every origin. Preflight results are
+// cached by browsers for MaxAge.
+type Policy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// AllowsOrigin reports whether origin matches one of the allowed origins.
+func (p Policy) AllowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		// https://*.example.com matches https://app.example.com but not
+		// https://example.com.
+		scheme, domain, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+domain) &&
+			len(origin) > len(scheme)+len("://")+len(domain)+1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Policy) allowsMethod(method string) bool {
+	for _, allowed := range p.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+
+	// Simple methods never need to be allowed.
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodPost
+}
+
+func (p Policy) allowsHeaders(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+
+		allowed := false
+		for _, h := range p.AllowedHeaders {
+			if strings.EqualFold(h, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Handler applies a Policy to the requests of a Gin router.
+type Handler struct {
+	mu     sync.RWMutex
+	policy Policy
+}
+
+func New(policy Policy) *Handler {
+	return &Handler{policy: policy}
+}
+
+// SetPolicy replaces the policy for the following requests.
+func (h *Handler) SetPolicy(policy Policy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.policy = policy
+}
+
+func (h *Handler) current() Policy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.policy
+}
+
+// Middleware adds the CORS headers to responses for allowed origins and
+// answers preflight requests itself. Preflights asking for an origin, method
+// or header the policy does not allow are rejected with 403.
+func (h *Handler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		p := h.current()
+		header := c.Writer.Header()
+		// Responses differ per origin unless every origin gets "*".
+		header.Add("Vary", "Origin")
+
+		requestedMethod := c.GetHeader("Access-Control-Request-Method")
+		preflight := c.Request.Method == http.MethodOptions && requestedMethod != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !p.AllowsOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// The browser hides the response from the page.
+			c.Next()
+			return
+		}
+
+		if p.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		} else if len(p.AllowedOrigins) == 1 && p.AllowedOrigins[0] == "*" {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if !preflight {
+			if len(p.ExposedHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+			}
+			c.Next()
+			return
+		}
+
+		requestedHeaders := c.GetHeader("Access-Control-Request-Headers")
+		if !p.allowsMethod(requestedMethod) || !p.allowsHeaders(requestedHeaders) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+		if len(p.AllowedHeaders) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+		}
+		if p.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,187 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAllowsOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{"every origin", []string{"*"}, "https://anything.example.org", true},
+		{"exact", []string{"https://app.example.com"}, "https://app.example.com", true},
+		{"case insensitive", []string{"https://App.Example.com"}, "https://app.EXAMPLE.com", true},
+		{"other origin", []string{"https://app.example.com"}, "https://api.example.com", false},
+		{"other scheme", []string{"https://app.example.com"}, "http://app.example.com", false},
+		{"other port", []string{"https://app.example.com"}, "https://app.example.com:8443", false},
+		{"none allowed", nil, "https://app.example.com", false},
+		{"subdomain", []string{"https://*.example.com"}, "https://app.example.com", true},
+		{"nested subdomain", []string{"https://*.example.com"}, "https://a.b.example.com", true},
+		{"wildcard excludes the domain", []string{"https://*.example.com"}, "https://example.com", false},
+		{"wildcard needs a label", []string{"https://*.example.com"}, "https://.example.com", false},
+		{"wildcard scheme", []string{"https://*.example.com"}, "http://app.example.com", false},
+		{"suffix without dot", []string{"https://*.example.com"}, "https://evilexample.com", false},
+		{"domain as subdomain", []string{"https://*.example.com"}, "https://example.com.evil.org", false},
+		{"second entry", []string{"https://a.example.com", "https://b.example.com"}, "https://b.example.com", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := (Policy{AllowedOrigins: tc.allowed}).AllowsOrigin(tc.origin); got != tc.want {
+				t.Errorf("AllowsOrigin(%q) with %v = %v, want %v", tc.origin, tc.allowed, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	open := Policy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"Grpc-Metadata-Request-Id"},
+		MaxAge:         10 * time.Minute,
+	}
+	credentials := open
+	credentials.AllowedOrigins = []string{"https://app.example.com"}
+	credentials.AllowCredentials = true
+	listed := open
+	listed.AllowedOrigins = []string{"https://a.example.com", "https://b.example.com"}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		method     string
+		headers    map[string]string
+		wantStatus int
+		// Response headers expected, "" for absent ones.
+		wantHeaders map[string]string
+	}{
+		{
+			name: "same origin request", policy: open, method: "GET",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""},
+		},
+		{
+			name: "any origin", policy: open, method: "GET",
+			headers:     map[string]string{"Origin": "https://other.example.org"},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Expose-Headers": "Grpc-Metadata-Request-Id", "Vary": "Origin"},
+		},
+		{
+			name: "listed origin is echoed", policy: listed, method: "GET",
+			headers:     map[string]string{"Origin": "https://b.example.com"},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://b.example.com"},
+		},
+		{
+			name: "origin not allowed", policy: listed, method: "GET",
+			headers:     map[string]string{"Origin": "https://evil.example.org"},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		{
+			name: "credentials", policy: credentials, method: "GET",
+			headers:     map[string]string{"Origin": "https://app.example.com"},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Credentials": "true"},
+		},
+		{
+			name: "preflight", policy: open, method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE", "Access-Control-Request-Headers": "authorization, content-type"},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "*",
+				"Access-Control-Allow-Methods":  "GET, POST, DELETE",
+				"Access-Control-Allow-Headers":  "Authorization, Content-Type",
+				"Access-Control-Max-Age":        "600",
+				"Access-Control-Expose-Headers": "",
+			},
+		},
+		{
+			name: "preflight of a simple method", policy: open, method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "HEAD"},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "preflight of a method not allowed", policy: open, method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "PATCH"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "preflight of a header not allowed", policy: open, method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Debug"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "preflight of an origin not allowed", policy: listed, method: "OPTIONS",
+			headers:     map[string]string{"Origin": "https://evil.example.org", "Access-Control-Request-Method": "GET"},
+			wantStatus:  http.StatusForbidden,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name: "options without preflight", policy: open, method: "OPTIONS",
+			headers:    map[string]string{"Origin": "https://app.example.com"},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(New(tc.policy).Middleware())
+			router.Any("/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tc.method, "/v1/users", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			for key, want := range tc.wantHeaders {
+				if got := rec.Header().Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+// A new policy applies to the following requests.
+func TestSetPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := New(Policy{AllowedOrigins: []string{"https://a.example.com"}})
+	router := gin.New()
+	router.Use(handler.Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	allowOrigin := func() string {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://b.example.com")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowOrigin(); got != "" {
+		t.Errorf("before: Access-Control-Allow-Origin = %q", got)
+	}
+	handler.SetPolicy(Policy{AllowedOrigins: []string{"https://a.example.com", "https://b.example.com"}})
+	if got := allowOrigin(); got != "https://b.example.com" {
+		t.Errorf("after: Access-Control-Allow-Origin = %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+// Package encryption encrypts secrets stored in the database, e.g. MFA shared
+// secrets, with AES-256-GCM under a key derived from the application secret.
+//
+// An encrypted value is "enc:v1:" followed by the base64 of nonce and
+// ciphertext, so encrypted and plaintext values can be told apart while old
+// rows are migrated. Changing the application secret makes stored values
+// unreadable.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const prefix = "enc:v1:"
+
+var ErrDecrypt = errors.New("encrypted value is invalid or was encrypted with another key")
+
+// Key encrypts and decrypts the values of one purpose.
+type Key struct {
+	aead cipher.AEAD
+}
+
+// NewKey derives the key of purpose from secret with HKDF-SHA256. Keys of
+// different purposes are independent, a value only decrypts with the key of
+// the purpose it was encrypted for.
+func NewKey(secret string, purpose string) (*Key, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte("go-auth "+purpose)), key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{aead: aead}, nil
+}
+
+// Encrypt returns plaintext encrypted with a random nonce.
+func (k *Key) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := k.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of a value returned by Encrypt.
+func (k *Key) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", ErrDecrypt
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+
+	nonce, ciphertext := sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():]
+	plaintext, err := k.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value has the format of Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
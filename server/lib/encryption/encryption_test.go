@@ -0,0 +1,71 @@
+package encryption
+
+import (
+	"errors"
+	"testing"
+)
+
+const testSecret = "Zq8x!Lm2#Vb7@Rt5$Wn1^Kp4&Hs9*Dj3"
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := newTestKey(t, testSecret, "mfa secret")
+
+	encrypted, err := key.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) {
+		t.Errorf("IsEncrypted(%q) = false", encrypted)
+	}
+	again, err := key.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == encrypted {
+		t.Error("encrypting twice gave the same value")
+	}
+
+	tampered := []byte(encrypted)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		key     *Key
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"same key", key, encrypted, "JBSWY3DPEHPK3PXP", false},
+		{"other purpose", newTestKey(t, testSecret, "other"), encrypted, "", true},
+		{"other secret", newTestKey(t, testSecret+"x", "mfa secret"), encrypted, "", true},
+		{"tampered", key, string(tampered), "", true},
+		{"truncated", key, prefix + "AAAA", "", true},
+		{"plaintext", key, "JBSWY3DPEHPK3PXP", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.key.Decrypt(tc.value)
+			if tc.wantErr {
+				if !errors.Is(err, ErrDecrypt) {
+					t.Errorf("Decrypt = %q, %v, want ErrDecrypt", got, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("Decrypt = %q, %v, want %q", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func newTestKey(t *testing.T, secret, purpose string) *Key {
+	t.Helper()
+
+	key, err := NewKey(secret, purpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
@@ -0,0 +1,244 @@
+package hasher
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	Argon2idID     = "argon2id"
+	ScryptID       = "scrypt"
+	Pbkdf2Sha256ID = "pbkdf2-sha256"
+	BcryptID       = "bcrypt"
+
+	// bcrypt rejects longer passwords.
+	BcryptMaxBytes = 72
+	// Block size r of new scrypt hashes.
+	ScryptBlockSize = 8
+
+	saltLength = 16
+	keyLength  = 32
+)
+
+// Bounds of the parameters of new and stored hashes. Stored hashes outside of
+// them are rejected as ErrUnknownHash: an imported hash must not make a login
+// allocate gigabytes or run for minutes, and argon2 panics on zero iterations
+// or parallelism.
+const (
+	MaxArgon2Memory     = 4 * 1024 * 1024 // KiB, 4 GiB
+	MaxArgon2Iterations = 64
+	MaxScryptCostLog2   = 24
+	// scrypt uses 128 * N * r bytes.
+	MaxScryptMemory     = 4 << 30
+	MinPbkdf2Iterations = 1000
+	MaxPbkdf2Iterations = 10000000
+	MaxBcryptCost       = 18
+
+	minHashLength = 16
+	maxHashLength = 64
+)
+
+// Argon2id hashes as "$argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$salt$hash".
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+func (h Argon2id) ID() string { return Argon2idID }
+
+func (h Argon2id) Hash(password string) (string, error) {
+	salt, err := newSalt(saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, keyLength)
+	params := fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2.Version, h.Memory, h.Iterations, h.Parallelism)
+
+	return encodePHC(Argon2idID, params, salt, key), nil
+}
+
+func (h Argon2id) Verify(password string, encoded string) (bool, error) {
+	p, m, t, par, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, t, m, par, uint32(len(p.hash)))
+	return subtle.ConstantTimeCompare(key, p.hash) == 1, nil
+}
+
+func (h Argon2id) NeedsRehash(encoded string) bool {
+	p, m, t, par, err := h.decode(encoded)
+	return err != nil || m != h.Memory || t != h.Iterations || par != h.Parallelism || len(p.hash) != keyLength
+}
+
+func (h Argon2id) decode(encoded string) (*phc, uint32, uint32, uint8, error) {
+	p, err := parsePHC(encoded)
+	if err != nil || p.id != Argon2idID {
+		return nil, 0, 0, 0, ErrUnknownHash
+	}
+
+	m, errM := strconv.ParseUint(p.params["m"], 10, 32)
+	t, errT := strconv.ParseUint(p.params["t"], 10, 32)
+	par, errP := strconv.ParseUint(p.params["p"], 10, 8)
+	if errM != nil || errT != nil || errP != nil || !validHashLength(p.hash) {
+		return nil, 0, 0, 0, ErrUnknownHash
+	}
+	// argon2 needs at least 8 KiB per lane.
+	if t < 1 || t > MaxArgon2Iterations || par < 1 || m < 8*par || m > MaxArgon2Memory {
+		return nil, 0, 0, 0, ErrUnknownHash
+	}
+
+	return p, uint32(m), uint32(t), uint8(par), nil
+}
+
+// Scrypt hashes as "$scrypt$ln=<log2 N>,r=<block size>,p=<parallelism>$salt$hash".
+type Scrypt struct {
+	LogN int
+	R    int
+	P    int
+}
+
+func (h Scrypt) ID() string { return ScryptID }
+
+func (h Scrypt) Hash(password string) (string, error) {
+	salt, err := newSalt(saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.LogN, h.R, h.P, keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return encodePHC(ScryptID, fmt.Sprintf("ln=%d,r=%d,p=%d", h.LogN, h.R, h.P), salt, key), nil
+}
+
+func (h Scrypt) Verify(password string, encoded string) (bool, error) {
+	p, ln, r, par, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := scrypt.Key([]byte(password), p.salt, 1<<ln, r, par, len(p.hash))
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(key, p.hash) == 1, nil
+}
+
+func (h Scrypt) NeedsRehash(encoded string) bool {
+	p, ln, r, par, err := h.decode(encoded)
+	return err != nil || ln != h.LogN || r != h.R || par != h.P || len(p.hash) != keyLength
+}
+
+func (h Scrypt) decode(encoded string) (*phc, int, int, int, error) {
+	p, err := parsePHC(encoded)
+	if err != nil || p.id != ScryptID {
+		return nil, 0, 0, 0, ErrUnknownHash
+	}
+
+	ln, errN := strconv.Atoi(p.params["ln"])
+	r, errR := strconv.Atoi(p.params["r"])
+	par, errP := strconv.Atoi(p.params["p"])
+	if errN != nil || errR != nil || errP != nil || !validHashLength(p.hash) {
+		return nil, 0, 0, 0, ErrUnknownHash
+	}
+	if ln < 1 || ln > MaxScryptCostLog2 || r < 1 || par < 1 || par > 16 || 128*(1<<ln)*r > MaxScryptMemory {
+		return nil, 0, 0, 0, ErrUnknownHash
+	}
+
+	return p, ln, r, par, nil
+}
+
+// Pbkdf2Sha256 hashes as "$pbkdf2-sha256$i=<iterations>$salt$hash".
+type Pbkdf2Sha256 struct {
+	Iterations int
+}
+
+func (h Pbkdf2Sha256) ID() string { return Pbkdf2Sha256ID }
+
+func (h Pbkdf2Sha256) Hash(password string) (string, error) {
+	salt, err := newSalt(saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, h.Iterations, keyLength, sha256.New)
+	return encodePHC(Pbkdf2Sha256ID, fmt.Sprintf("i=%d", h.Iterations), salt, key), nil
+}
+
+func (h Pbkdf2Sha256) Verify(password string, encoded string) (bool, error) {
+	p, iterations, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := pbkdf2.Key([]byte(password), p.salt, iterations, len(p.hash), sha256.New)
+	return subtle.ConstantTimeCompare(key, p.hash) == 1, nil
+}
+
+func (h Pbkdf2Sha256) NeedsRehash(encoded string) bool {
+	p, iterations, err := h.decode(encoded)
+	return err != nil || iterations != h.Iterations || len(p.hash) != keyLength
+}
+
+func (h Pbkdf2Sha256) decode(encoded string) (*phc, int, error) {
+	p, err := parsePHC(encoded)
+	if err != nil || p.id != Pbkdf2Sha256ID {
+		return nil, 0, ErrUnknownHash
+	}
+
+	iterations, err := strconv.Atoi(p.params["i"])
+	if err != nil || iterations < 1 || iterations > MaxPbkdf2Iterations || !validHashLength(p.hash) {
+		return nil, 0, ErrUnknownHash
+	}
+
+	return p, iterations, nil
+}
+
+// Bcrypt hashes in the bcrypt "$2a$<cost>$<salt+hash>" format.
+type Bcrypt struct {
+	Cost int
+}
+
+func (h Bcrypt) ID() string { return BcryptID }
+
+func (h Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h Bcrypt) Verify(password string, encoded string) (bool, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil || cost > MaxBcryptCost {
+		return false, ErrUnknownHash
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (h Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+func validHashLength(hash []byte) bool {
+	return len(hash) >= minHashLength && len(hash) <= maxHashLength
+}
@@ -0,0 +1,161 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters keep the tests fast.
+var testHashers = []Hasher{
+	Argon2id{Memory: 64, Iterations: 1, Parallelism: 1},
+	Scrypt{LogN: 4, R: 8, P: 1},
+	Pbkdf2Sha256{Iterations: 1000},
+	Bcrypt{Cost: bcrypt.MinCost},
+}
+
+func TestHashVerify(t *testing.T) {
+	for _, h := range testHashers {
+		t.Run(h.ID(), func(t *testing.T) {
+			encoded, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if identify(encoded) != h.ID() {
+				t.Errorf("identify(%q) = %q, want %q", encoded, identify(encoded), h.ID())
+			}
+
+			for _, tc := range []struct {
+				password string
+				want     bool
+			}{
+				{"correct horse", true},
+				{"correct horse ", false},
+				{"", false},
+			} {
+				ok, err := h.Verify(tc.password, encoded)
+				if err != nil || ok != tc.want {
+					t.Errorf("Verify(%q) = %v, %v, want %v", tc.password, ok, err, tc.want)
+				}
+			}
+
+			if h.NeedsRehash(encoded) {
+				t.Errorf("NeedsRehash of a fresh hash is true")
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     Hasher
+		current Hasher
+		want    bool
+	}{
+		{"argon2id same", Argon2id{64, 1, 1}, Argon2id{64, 1, 1}, false},
+		{"argon2id memory", Argon2id{64, 1, 1}, Argon2id{128, 1, 1}, true},
+		{"argon2id iterations", Argon2id{64, 1, 1}, Argon2id{64, 2, 1}, true},
+		{"scrypt cost", Scrypt{4, 8, 1}, Scrypt{5, 8, 1}, true},
+		{"pbkdf2 iterations", Pbkdf2Sha256{1000}, Pbkdf2Sha256{2000}, true},
+		{"bcrypt cost", Bcrypt{bcrypt.MinCost}, Bcrypt{bcrypt.MinCost + 1}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := tc.old.Hash("secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tc.current.NeedsRehash(encoded); got != tc.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Stored hashes come from imports and old systems. Parameters that would
+// panic or exhaust the server must be rejected before any work is done.
+func TestVerifyRejectsUnsafeHashes(t *testing.T) {
+	salt := make([]byte, saltLength)
+	hash := make([]byte, keyLength)
+	phcHash := func(id, params string) string {
+		return encodePHC(id, params, salt, hash)
+	}
+
+	tests := []struct {
+		name    string
+		hasher  Hasher
+		encoded string
+	}{
+		{"argon2id zero iterations", Argon2id{}, phcHash(Argon2idID, "v=19$m=64,t=0,p=1")},
+		{"argon2id zero parallelism", Argon2id{}, phcHash(Argon2idID, "v=19$m=64,t=1,p=0")},
+		{"argon2id huge memory", Argon2id{}, phcHash(Argon2idID, "v=19$m=4294967295,t=1,p=1")},
+		{"argon2id many iterations", Argon2id{}, phcHash(Argon2idID, fmt.Sprintf("v=19$m=64,t=%d,p=1", MaxArgon2Iterations+1))},
+		{"argon2id memory below lanes", Argon2id{}, phcHash(Argon2idID, "v=19$m=8,t=1,p=4")},
+		{"argon2id missing parameter", Argon2id{}, phcHash(Argon2idID, "v=19$m=64,t=1")},
+		{"argon2id short hash", Argon2id{}, encodePHC(Argon2idID, "v=19$m=64,t=1,p=1", salt, hash[:4])},
+		{"argon2id long hash", Argon2id{}, encodePHC(Argon2idID, "v=19$m=64,t=1,p=1", salt, make([]byte, 1<<20))},
+		{"scrypt zero r", Scrypt{}, phcHash(ScryptID, "ln=4,r=0,p=1")},
+		{"scrypt zero p", Scrypt{}, phcHash(ScryptID, "ln=4,r=8,p=0")},
+		{"scrypt huge cost", Scrypt{}, phcHash(ScryptID, "ln=30,r=8,p=1")},
+		{"scrypt huge memory", Scrypt{}, phcHash(ScryptID, "ln=20,r=64,p=1")},
+		{"pbkdf2 zero iterations", Pbkdf2Sha256{}, phcHash(Pbkdf2Sha256ID, "i=0")},
+		{"pbkdf2 huge iterations", Pbkdf2Sha256{}, phcHash(Pbkdf2Sha256ID, "i=2000000000")},
+		{"bcrypt huge cost", Bcrypt{}, "$2a$31$" + strings.Repeat("a", 53)},
+		{"wrong algorithm", Argon2id{}, phcHash(ScryptID, "ln=4,r=8,p=1")},
+		{"not base64", Pbkdf2Sha256{}, "$pbkdf2-sha256$i=1000$!!!$!!!"},
+		{"not phc", Argon2id{}, "plaintext"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := tc.hasher.Verify("secret", tc.encoded)
+			if ok || !errors.Is(err, ErrUnknownHash) {
+				t.Errorf("Verify = %v, %v, want ErrUnknownHash", ok, err)
+			}
+		})
+	}
+}
+
+func TestRegistryVerify(t *testing.T) {
+	current := Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+	legacy := Pbkdf2Sha256{Iterations: 1000}
+	r := NewRegistry(current, legacy)
+
+	legacyHash, err := legacy.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentHash, err := r.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		encoded     string
+		wantOK      bool
+		wantRehash  bool
+		wantUnknown bool
+	}{
+		{"current", "secret", currentHash, true, false, false},
+		{"current wrong password", "wrong", currentHash, false, false, false},
+		{"legacy is rehashed", "secret", legacyHash, true, true, false},
+		{"legacy wrong password", "wrong", legacyHash, false, false, false},
+		{"unregistered algorithm", "secret", "$md5$x$y$z", false, false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, rehash, err := r.Verify(tc.password, tc.encoded)
+			if ok != tc.wantOK || rehash != tc.wantRehash || errors.Is(err, ErrUnknownHash) != tc.wantUnknown {
+				t.Errorf("Verify = %v, %v, %v, want %v, %v, unknown %v", ok, rehash, err, tc.wantOK, tc.wantRehash, tc.wantUnknown)
+			}
+		})
+	}
+}
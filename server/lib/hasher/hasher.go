@@ -0,0 +1,162 @@
+// Package hasher hashes and verifies passwords with several algorithms. Hashes
+// are stored in the PHC string format, "$<id>$<params>$<salt>$<hash>", except
+// bcrypt which keeps its own "$2a$" format, so the algorithm and parameters of
+// every stored hash are known. Hashes imported from other systems can be
+// stored as-is when they use one of the supported formats.
+package hasher
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher implements one password hashing algorithm.
+type Hasher interface {
+	// ID is the PHC identifier of the algorithm.
+	ID() string
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (bool, error)
+	// NeedsRehash reports whether encoded was created with parameters
+	// other than the ones of this hasher.
+	NeedsRehash(encoded string) bool
+}
+
+// Registry verifies hashes of every registered algorithm and creates new ones
+// with the default hasher.
+type Registry struct {
+	defaultHasher Hasher
+	hashers       map[string]Hasher
+
+	dummyOnce sync.Once
+	dummyHash string
+	dummyErr  error
+}
+
+func NewRegistry(defaultHasher Hasher, others ...Hasher) *Registry {
+	r := &Registry{
+		defaultHasher: defaultHasher,
+		hashers:       map[string]Hasher{defaultHasher.ID(): defaultHasher},
+	}
+	for _, h := range others {
+		if _, ok := r.hashers[h.ID()]; !ok {
+			r.hashers[h.ID()] = h
+		}
+	}
+
+	return r
+}
+
+// Hash hashes password with the default hasher.
+func (r *Registry) Hash(password string) (string, error) {
+	return r.defaultHasher.Hash(password)
+}
+
+// DummyHash returns a hash of a random password made with the default hasher.
+// Verifying a password against it takes as long as verifying a real one, which
+// hides whether an account exists. It is created on first use.
+func (r *Registry) DummyHash() (string, error) {
+	r.dummyOnce.Do(func() {
+		var password []byte
+		if password, r.dummyErr = newSalt(32); r.dummyErr != nil {
+			return
+		}
+		r.dummyHash, r.dummyErr = r.defaultHasher.Hash(base64.RawStdEncoding.EncodeToString(password))
+	})
+
+	return r.dummyHash, r.dummyErr
+}
+
+// Verify checks password against encoded. needsRehash is true when the
+// password is correct but encoded does not use the default algorithm and
+// parameters.
+func (r *Registry) Verify(password string, encoded string) (ok bool, needsRehash bool, err error) {
+	h, ok := r.hashers[identify(encoded)]
+	if !ok {
+		return false, false, ErrUnknownHash
+	}
+
+	ok, err = h.Verify(password, encoded)
+	if err != nil || !ok {
+		return false, false, err
+	}
+
+	return true, h != r.defaultHasher || h.NeedsRehash(encoded), nil
+}
+
+// identify returns the algorithm id of an encoded hash.
+func identify(encoded string) string {
+	if strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$") {
+		return BcryptID
+	}
+
+	parts := strings.SplitN(encoded, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// phc is a parsed "$id$params$salt$hash" string.
+type phc struct {
+	id     string
+	params map[string]string
+	salt   []byte
+	hash   []byte
+}
+
+func parsePHC(encoded string) (*phc, error) {
+	parts := strings.Split(encoded, "$")
+	// The argon2 version is an extra "v=19" segment before the parameters.
+	if len(parts) == 6 && strings.HasPrefix(parts[2], "v=") {
+		parts = append(parts[:2], parts[3:]...)
+	}
+	if len(parts) != 5 || parts[0] != "" {
+		return nil, ErrUnknownHash
+	}
+
+	params := map[string]string{}
+	for _, param := range strings.Split(parts[2], ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, ErrUnknownHash
+		}
+		params[key] = value
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, ErrUnknownHash
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, ErrUnknownHash
+	}
+
+	return &phc{id: parts[1], params: params, salt: salt, hash: hash}, nil
+}
+
+func encodePHC(id string, params string, salt []byte, hash []byte) string {
+	return fmt.Sprintf("$%s$%s$%s$%s",
+		id,
+		params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	)
+}
+
+func newSalt(n int) ([]byte, error) {
+	salt := make([]byte, n)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
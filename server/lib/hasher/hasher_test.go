@@ -0,0 +1,120 @@
+package hasher
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		encoded string
+		want    string
+	}{
+		{"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA", Argon2idID},
+		{"$scrypt$ln=4,r=8,p=1$c2FsdA$aGFzaA", ScryptID},
+		{"$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA", Pbkdf2Sha256ID},
+		{"$2a$10$abcdefghijklmnopqrstuv", BcryptID},
+		{"$2b$10$abcdefghijklmnopqrstuv", BcryptID},
+		{"$2y$10$abcdefghijklmnopqrstuv", BcryptID},
+		{"plaintext", ""},
+		{"argon2id$v=19", ""},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if got := identify(tc.encoded); got != tc.want {
+			t.Errorf("identify(%q) = %q, want %q", tc.encoded, got, tc.want)
+		}
+	}
+}
+
+func TestParsePHC(t *testing.T) {
+	tests := []struct {
+		name       string
+		encoded    string
+		wantID     string
+		wantParams map[string]string
+		wantErr    bool
+	}{
+		{"argon2 version", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA", Argon2idID, map[string]string{"m": "64", "t": "1", "p": "1"}, false},
+		{"single parameter", "$pbkdf2-sha256$i=1000$c2FsdA$aGFzaA", Pbkdf2Sha256ID, map[string]string{"i": "1000"}, false},
+		{"missing hash", "$pbkdf2-sha256$i=1000$c2FsdA", "", nil, true},
+		{"extra segment", "$pbkdf2-sha256$i=1000$x$c2FsdA$aGFzaA", "", nil, true},
+		{"no leading dollar", "pbkdf2-sha256$i=1000$c2FsdA$aGFzaA$", "", nil, true},
+		{"parameter without value", "$pbkdf2-sha256$i$c2FsdA$aGFzaA", "", nil, true},
+		{"padded base64", "$pbkdf2-sha256$i=1000$c2FsdA==$aGFzaA", "", nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := parsePHC(tc.encoded)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("parsePHC succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if parsed.id != tc.wantID || string(parsed.salt) != "salt" || string(parsed.hash) != "hash" {
+				t.Errorf("parsed %s, salt %q, hash %q", parsed.id, parsed.salt, parsed.hash)
+			}
+			if len(parsed.params) != len(tc.wantParams) {
+				t.Errorf("params = %v, want %v", parsed.params, tc.wantParams)
+			}
+			for key, value := range tc.wantParams {
+				if parsed.params[key] != value {
+					t.Errorf("param %s = %q, want %q", key, parsed.params[key], value)
+				}
+			}
+		})
+	}
+}
+
+// Hashes produced by other implementations verify, so existing hashes can be
+// imported.
+func TestVerifyKnownHashes(t *testing.T) {
+	// RFC 7914 section 12, truncated to the key length: PBKDF2 output
+	// blocks do not depend on the length requested.
+	scryptKey, _ := hex.DecodeString("fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162")
+
+	tests := []struct {
+		name     string
+		hasher   Hasher
+		password string
+		encoded  string
+	}{
+		{
+			"argon2id reference",
+			Argon2id{},
+			"password",
+			"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		},
+		{
+			"scrypt rfc 7914",
+			Scrypt{},
+			"password",
+			encodePHC(ScryptID, "ln=10,r=8,p=16", []byte("NaCl"), scryptKey),
+		},
+		{
+			"bcrypt",
+			Bcrypt{},
+			"U*U",
+			"$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := tc.hasher.Verify(tc.password, tc.encoded)
+			if err != nil || !ok {
+				t.Errorf("Verify = %v, %v, want true", ok, err)
+			}
+			if ok, _ := tc.hasher.Verify(tc.password+"x", tc.encoded); ok {
+				t.Error("Verify of another password = true")
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package hasher
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrQueueFull = errors.New("password hashing queue is full")
+	// ErrPanicked is returned by Do when the work panicked. The worker
+	// survives, a bad stored hash must not take the process down.
+	ErrPanicked = errors.New("password hashing failed")
+)
+
+// latencyBuckets are the upper bounds of the hash latency histogram.
+var latencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+}
+
+// Pool runs password hashing on a fixed number of workers so bursts of logins
+// cannot occupy every CPU. Work waits in a bounded queue; when the queue is
+// full new work is rejected with ErrQueueFull instead of piling up.
+type Pool struct {
+	jobs    chan *job
+	workers int
+
+	inFlight  atomic.Int64
+	completed atomic.Int64
+	rejected  atomic.Int64
+	canceled  atomic.Int64
+	hashNanos atomic.Int64
+	waitNanos atomic.Int64
+	// One counter per latency bucket plus one for slower hashes.
+	latency []atomic.Int64
+}
+
+type job struct {
+	ctx      context.Context
+	fn       func()
+	queuedAt time.Time
+	done     chan struct{}
+	panicked bool
+}
+
+// NewPool starts workers goroutines serving a queue of queueSize jobs.
+func NewPool(workers int, queueSize int) *Pool {
+	p := &Pool{
+		jobs:    make(chan *job, queueSize),
+		workers: workers,
+		latency: make([]atomic.Int64, len(latencyBuckets)+1),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+// Do runs fn on a worker and waits for it. It returns ErrQueueFull when the
+// queue is full and ctx.Err() when ctx ends first; fn then still completes in
+// the background if it already started, but its result should be ignored.
+func (p *Pool) Do(ctx context.Context, fn func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	j := &job{ctx: ctx, fn: fn, queuedAt: time.Now(), done: make(chan struct{})}
+	select {
+	case p.jobs <- j:
+	default:
+		p.rejected.Add(1)
+		return ErrQueueFull
+	}
+
+	select {
+	case <-j.done:
+		if j.panicked {
+			return ErrPanicked
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	for j := range p.jobs {
+		start := time.Now()
+		p.waitNanos.Add(int64(start.Sub(j.queuedAt)))
+
+		// Skip work nobody is waiting for anymore.
+		if j.ctx.Err() != nil {
+			p.canceled.Add(1)
+			continue
+		}
+
+		p.inFlight.Add(1)
+		p.run(j)
+		p.inFlight.Add(-1)
+
+		elapsed := time.Since(start)
+		p.observe(elapsed)
+		close(j.done)
+	}
+}
+
+func (p *Pool) run(j *job) {
+	defer func() {
+		if recover() != nil {
+			j.panicked = true
+		}
+	}()
+
+	j.fn()
+}
+
+func (p *Pool) observe(elapsed time.Duration) {
+	p.completed.Add(1)
+	p.hashNanos.Add(int64(elapsed))
+
+	for i, bound := range latencyBuckets {
+		if elapsed <= bound {
+			p.latency[i].Add(1)
+			return
+		}
+	}
+	p.latency[len(latencyBuckets)].Add(1)
+}
+
+// Metrics returns a snapshot of the pool counters. Latencies are in seconds,
+// the histogram counts hashes per upper bound in milliseconds.
+func (p *Pool) Metrics() map[string]interface{} {
+	histogram := map[string]int64{}
+	for i, bound := range latencyBuckets {
+		histogram[strconv.FormatInt(bound.Milliseconds(), 10)] = p.latency[i].Load()
+	}
+	histogram["+Inf"] = p.latency[len(latencyBuckets)].Load()
+
+	return map[string]interface{}{
+		"workers":                 p.workers,
+		"queue_capacity":          cap(p.jobs),
+		"queue_depth":             len(p.jobs),
+		"in_flight":               p.inFlight.Load(),
+		"completed_total":         p.completed.Load(),
+		"rejected_total":          p.rejected.Load(),
+		"canceled_total":          p.canceled.Load(),
+		"hash_seconds_sum":        time.Duration(p.hashNanos.Load()).Seconds(),
+		"queue_wait_seconds_sum":  time.Duration(p.waitNanos.Load()).Seconds(),
+		"hash_latency_ms_buckets": histogram,
+	}
+}
+
+// Publish exports Metrics as the expvar variable name.
+func (p *Pool) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return p.Metrics()
+	}))
+}
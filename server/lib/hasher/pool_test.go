@@ -0,0 +1,161 @@
+package hasher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolDo(t *testing.T) {
+	p := NewPool(1, 1)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		fn      func()
+		want    error
+		wantRan bool
+	}{
+		{"runs", context.Background(), func() {}, nil, true},
+		{"panics", context.Background(), func() { panic("bad hash") }, ErrPanicked, true},
+		{"survives a panic", context.Background(), func() {}, nil, true},
+		{"canceled context", canceled, func() {}, context.Canceled, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ran := false
+			err := p.Do(tc.ctx, func() {
+				ran = true
+				tc.fn()
+			})
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Do = %v, want %v", err, tc.want)
+			}
+			if ran != tc.wantRan {
+				t.Errorf("ran = %v, want %v", ran, tc.wantRan)
+			}
+		})
+	}
+}
+
+// With every worker busy and the queue full, further work is rejected
+// immediately instead of waiting.
+func TestPoolQueueFull(t *testing.T) {
+	p := NewPool(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.Do(context.Background(), func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		p.Do(context.Background(), func() {})
+	}()
+	waitFor(t, func() bool { return p.Metrics()["queue_depth"] == 1 })
+
+	if err := p.Do(context.Background(), func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Do with a full queue = %v, want %v", err, ErrQueueFull)
+	}
+
+	close(release)
+	wg.Wait()
+
+	metrics := p.Metrics()
+	want := map[string]interface{}{
+		"completed_total": int64(2),
+		"rejected_total":  int64(1),
+		"in_flight":       int64(0),
+		"queue_depth":     0,
+	}
+	for key, value := range want {
+		if metrics[key] != value {
+			t.Errorf("%s = %v, want %v", key, metrics[key], value)
+		}
+	}
+}
+
+// Work whose caller gave up while it was queued is skipped.
+func TestPoolSkipsCanceledWork(t *testing.T) {
+	p := NewPool(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go p.Do(context.Background(), func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var ran atomic.Bool
+	if err := p.Do(ctx, func() { ran.Store(true) }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	waitFor(t, func() bool { return p.Metrics()["canceled_total"] == int64(1) })
+	if ran.Load() {
+		t.Error("canceled work ran")
+	}
+}
+
+// The pool never runs more work at once than it has workers.
+func TestPoolBoundsConcurrency(t *testing.T) {
+	const workers = 3
+	p := NewPool(workers, 100)
+
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Do(context.Background(), func() {
+				n := running.Add(1)
+				for {
+					old := peak.Load()
+					if n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > workers {
+		t.Errorf("%d hashes ran at once, want at most %d", peak.Load(), workers)
+	}
+}
+
+// waitFor polls cond until it holds or a second passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
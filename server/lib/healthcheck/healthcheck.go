@@ -0,0 +1,294 @@
+// Package healthcheck reports whether the service and its dependencies are
+// healthy over the gRPC health protocol and HTTP probes.
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Statuses of a check in the /readyz response.
+const (
+	statusUp      = "up"
+	statusDown    = "down"
+	statusTimeout = "timeout"
+)
+
+// Check tests one dependency. The service is not ready while a critical check
+// fails, the other checks are only reported.
+type Check struct {
+	Name     string
+	Critical bool
+	Fn       func(ctx context.Context) error
+}
+
+// Result is the outcome of a check as shown by /readyz. The error is logged
+// but not shown, it may name internal hosts.
+type Result struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	err      error
+}
+
+// Checker runs the checks and keeps the gRPC health server up to date. The
+// services passed to New share the status of the whole service.
+type Checker struct {
+	server   *health.Server
+	services []string
+	timeout  time.Duration
+	logger   *logrus.Logger
+
+	mu      sync.Mutex
+	checks  []Check
+	results map[string]Result
+	// Whether the critical checks of the last run passed.
+	ready    bool
+	stopping atomic.Bool
+	// Closed by Shutdown to end the Watch streams.
+	stopped chan struct{}
+}
+
+// New returns a Checker giving each check timeout to finish. Until the first
+// run the service is NOT_SERVING.
+func New(server *health.Server, timeout time.Duration, logger *logrus.Logger, services ...string) *Checker {
+	c := &Checker{
+		server:   server,
+		services: append([]string{""}, services...),
+		timeout:  timeout,
+		logger:   logger,
+		results:  map[string]Result{},
+		stopped:  make(chan struct{}),
+	}
+	c.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return c
+}
+
+func (c *Checker) Add(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check)
+}
+
+// Run runs the checks every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll runs every check concurrently, updates the serving status and
+// reports whether the critical checks passed.
+func (c *Checker) CheckAll(ctx context.Context) (map[string]Result, bool) {
+	c.mu.Lock()
+	checks := c.checks
+	c.mu.Unlock()
+
+	results := make(map[string]Result, len(checks))
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			resultsMu.Lock()
+			results[check.Name] = result
+			resultsMu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	// Stopped checks say nothing about the dependencies.
+	if ctx.Err() != nil {
+		return results, false
+	}
+
+	ready := true
+	for _, result := range results {
+		if result.Critical && result.Status != statusUp {
+			ready = false
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, result := range results {
+		previous, seen := c.results[name]
+		switch {
+		case result.Status != statusUp && (!seen || previous.Status == statusUp):
+			c.logger.Warnf("Health check %s failed: %v", name, result.err)
+		case result.Status == statusUp && seen && previous.Status != statusUp:
+			c.logger.Infof("Health check %s recovered", name)
+		}
+	}
+	c.results = results
+	c.ready = ready
+
+	if ready {
+		c.setStatus(healthpb.HealthCheckResponse_SERVING)
+	} else {
+		c.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	return results, ready
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// Checks that ignore ctx do not hold up the others.
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{Status: statusUp, Critical: check.Critical, err: err}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Status = statusTimeout
+	case err != nil:
+		result.Status = statusDown
+	}
+
+	return result
+}
+
+// Shutdown sets the service NOT_SERVING for good, so clients and load
+// balancers move away before the servers stop, and ends the Watch streams so
+// they do not hold up a graceful stop of the gRPC server.
+func (c *Checker) Shutdown() {
+	if c.stopping.Swap(true) {
+		return
+	}
+
+	c.server.Shutdown()
+	close(c.stopped)
+}
+
+// Server returns the gRPC health service to register. Unlike the plain
+// health server it ends Watch streams on Shutdown.
+func (c *Checker) Server() healthpb.HealthServer {
+	return &healthServer{Server: c.server, stopped: c.stopped}
+}
+
+// watchGrace is how long a Watch stream may take to send NOT_SERVING after
+// Shutdown before it is ended anyway.
+const watchGrace = time.Second
+
+type healthServer struct {
+	*health.Server
+	stopped <-chan struct{}
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	watch := &watchStream{Health_WatchServer: stream, ctx: ctx, notServing: make(chan struct{})}
+	go func() {
+		select {
+		case <-s.stopped:
+			// Let the client see NOT_SERVING before the stream ends.
+			select {
+			case <-watch.notServing:
+			case <-time.After(watchGrace):
+			case <-ctx.Done():
+			}
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return s.Server.Watch(req, watch)
+}
+
+// watchStream is a Watch stream ending with ctx, which notes when a status
+// other than SERVING was sent.
+type watchStream struct {
+	healthpb.Health_WatchServer
+	ctx        context.Context
+	notServing chan struct{}
+	once       sync.Once
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(resp *healthpb.HealthCheckResponse) error {
+	err := s.Health_WatchServer.Send(resp)
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		s.once.Do(func() { close(s.notServing) })
+	}
+
+	return err
+}
+
+func (c *Checker) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	// The health server ignores updates after Shutdown.
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+// Liveness answers /healthz: the process is up and serving HTTP. It does not
+// depend on other services, so their outages do not restart the service.
+func (c *Checker) Liveness() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// Readiness answers /readyz with the results of the last run of the checks,
+// with 503 while a critical check fails, before the first run and while the
+// service shuts down. Probes do not run the checks themselves, so frequent
+// probes do not load the dependencies.
+func (c *Checker) Readiness() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if c.stopping.Load() {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+			return
+		}
+
+		c.mu.Lock()
+		results, ready := c.results, c.ready
+		c.mu.Unlock()
+
+		if !ready {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": results})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"status": "ready", "checks": results})
+	}
+}
@@ -0,0 +1,179 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestChecker(checks ...Check) *Checker {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	c := New(health.NewServer(), 50*time.Millisecond, logger, "test.Service")
+	for _, check := range checks {
+		c.Add(check)
+	}
+
+	return c
+}
+
+func up(ctx context.Context) error { return nil }
+
+func down(ctx context.Context) error { return errors.New("down") }
+
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCheckAll(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantReady  bool
+		wantStatus map[string]string
+	}{
+		{"no checks", nil, true, map[string]string{}},
+		{"all up", []Check{{"db", true, up}, {"mq", false, up}}, true, map[string]string{"db": statusUp, "mq": statusUp}},
+		{"optional down", []Check{{"db", true, up}, {"mq", false, down}}, true, map[string]string{"db": statusUp, "mq": statusDown}},
+		{"critical down", []Check{{"db", true, down}, {"mq", false, up}}, false, map[string]string{"db": statusDown, "mq": statusUp}},
+		{"critical timeout", []Check{{"db", true, hang}}, false, map[string]string{"db": statusTimeout}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestChecker(tc.checks...)
+
+			results, ready := c.CheckAll(context.Background())
+			if ready != tc.wantReady {
+				t.Errorf("ready = %v, want %v", ready, tc.wantReady)
+			}
+			for name, want := range tc.wantStatus {
+				if got := results[name].Status; got != want {
+					t.Errorf("%s = %s, want %s", name, got, want)
+				}
+			}
+
+			wantServing := healthpb.HealthCheckResponse_NOT_SERVING
+			if tc.wantReady {
+				wantServing = healthpb.HealthCheckResponse_SERVING
+			}
+			resp, err := c.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: "test.Service"})
+			if err != nil || resp.GetStatus() != wantServing {
+				t.Errorf("Check = %v, %v, want %v", resp.GetStatus(), err, wantServing)
+			}
+		})
+	}
+}
+
+// Probes answer with the last run of the checks instead of running them.
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var runs atomic.Int32
+	healthy := atomic.Bool{}
+	c := newTestChecker(Check{Name: "db", Critical: true, Fn: func(ctx context.Context) error {
+		runs.Add(1)
+		if !healthy.Load() {
+			return errors.New("down")
+		}
+		return nil
+	}})
+	router := gin.New()
+	router.GET("/readyz", c.Readiness())
+	probe := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return w.Code
+	}
+
+	tests := []struct {
+		name     string
+		before   func()
+		wantCode int
+		wantRuns int32
+	}{
+		{"before the first run", func() {}, http.StatusServiceUnavailable, 0},
+		{"healthy run", func() { healthy.Store(true); c.CheckAll(context.Background()) }, http.StatusOK, 1},
+		{"dependency down but not checked yet", func() { healthy.Store(false) }, http.StatusOK, 1},
+		{"failed run", func() { c.CheckAll(context.Background()) }, http.StatusServiceUnavailable, 2},
+		{"shutting down", func() { healthy.Store(true); c.CheckAll(context.Background()); c.Shutdown() }, http.StatusServiceUnavailable, 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.before()
+			for i := 0; i < 3; i++ {
+				if code := probe(); code != tc.wantCode {
+					t.Errorf("probe %d = %d, want %d", i, code, tc.wantCode)
+				}
+			}
+			if got := runs.Load(); got != tc.wantRuns {
+				t.Errorf("checks ran %d times, want %d", got, tc.wantRuns)
+			}
+		})
+	}
+}
+
+// Watch streams end on Shutdown after sending NOT_SERVING, so a graceful stop
+// of the gRPC server does not wait for the clients to hang up.
+func TestShutdownEndsWatch(t *testing.T) {
+	c := newTestChecker(Check{Name: "db", Critical: true, Fn: up})
+	c.CheckAll(context.Background())
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, c.Server())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "test.Service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("first status = %v, %v, want SERVING", resp.GetStatus(), err)
+	}
+
+	c.Shutdown()
+
+	if resp, err := stream.Recv(); err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("status after Shutdown = %v, %v, want NOT_SERVING", resp.GetStatus(), err)
+	}
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("stream still open after Shutdown")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(watchGrace + time.Second):
+		t.Fatal("GracefulStop waits for the Watch stream")
+	}
+}
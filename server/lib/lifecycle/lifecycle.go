@@ -0,0 +1,139 @@
+// Package lifecycle runs the components of the service until it is asked to
+// stop and then shuts them down in order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stopper struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+type failure struct {
+	name string
+	err  error
+}
+
+// Manager starts long running components with Go and stops the components
+// registered with OnStop once the process receives SIGINT or SIGTERM or a
+// component fails.
+type Manager struct {
+	timeout time.Duration
+	logger  *logrus.Logger
+
+	mu       sync.Mutex
+	stoppers []stopper
+	stopping []func()
+	failures chan failure
+}
+
+// New returns a Manager giving the components timeout to stop.
+func New(timeout time.Duration, logger *logrus.Logger) *Manager {
+	return &Manager{
+		timeout:  timeout,
+		logger:   logger,
+		failures: make(chan failure, 1),
+	}
+}
+
+// Go runs fn in a goroutine. When fn returns an error the service shuts down.
+// fn should return nil once it was stopped by its OnStop function.
+func (m *Manager) Go(name string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			select {
+			case m.failures <- failure{name: name, err: err}:
+			default:
+				// The service is shutting down already.
+				m.logger.Errorf("%s failed: %v", name, err)
+			}
+		}
+	}()
+}
+
+// OnStop registers stop to be called on shutdown. Like deferred calls they
+// run in reverse order, so components stop before the ones they use, e.g.
+// the servers before the database. Every stop shares the deadline of the
+// shutdown and should give up once ctx is done.
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stoppers = append(m.stoppers, stopper{name: name, stop: stop})
+}
+
+// OnStopping registers fn to be called as soon as shutdown begins, before any
+// component stops, e.g. to fail health checks.
+func (m *Manager) OnStopping(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stopping = append(m.stopping, fn)
+}
+
+// Wait blocks until a signal arrives or a component fails, then stops every
+// component. It returns the failure that caused the shutdown and the errors
+// of the components that did not stop cleanly.
+func (m *Manager) Wait() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var cause error
+	select {
+	case sig := <-signals:
+		m.logger.Infof("Received %s, shutting down", sig)
+	case f := <-m.failures:
+		cause = fmt.Errorf("%s: %w", f.name, f.err)
+		m.logger.Errorf("%s failed, shutting down: %v", f.name, f.err)
+	}
+
+	// A second signal skips the graceful shutdown.
+	go func() {
+		sig := <-signals
+		m.logger.Warnf("Received %s during shutdown, exiting", sig)
+		os.Exit(1)
+	}()
+
+	return errors.Join(cause, m.Shutdown())
+}
+
+// Shutdown stops every component within the timeout.
+func (m *Manager) Shutdown() error {
+	m.mu.Lock()
+	stopping := m.stopping
+	stoppers := m.stoppers
+	m.mu.Unlock()
+
+	for _, fn := range stopping {
+		fn()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(stoppers) - 1; i >= 0; i-- {
+		s := stoppers[i]
+		started := time.Now()
+		if err := s.stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping %s: %w", s.name, err))
+			m.logger.Errorf("Failed to stop %s: %v", s.name, err)
+			continue
+		}
+		m.logger.Infof("Stopped %s in %s", s.name, time.Since(started).Round(time.Millisecond))
+	}
+
+	return errors.Join(errs...)
+}
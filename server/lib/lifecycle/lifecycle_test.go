@@ -0,0 +1,175 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestManager(timeout time.Duration) *Manager {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return New(timeout, logger)
+}
+
+// recorder keeps the order in which components stopped.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, name)
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return strings.Join(r.calls, " ")
+}
+
+func TestShutdown(t *testing.T) {
+	errStuck := errors.New("stuck")
+
+	tests := []struct {
+		name string
+		// Stop functions registered in order, by name.
+		stoppers  map[string]func(ctx context.Context) error
+		order     []string
+		wantCalls string
+		wantErrs  []string
+	}{
+		{
+			name: "reverse order",
+			stoppers: map[string]func(ctx context.Context) error{
+				"database": nil, "consumer": nil, "grpc": nil,
+			},
+			order:     []string{"database", "consumer", "grpc"},
+			wantCalls: "stopping grpc consumer database",
+		},
+		{
+			name: "failures do not stop the others",
+			stoppers: map[string]func(ctx context.Context) error{
+				"database": nil,
+				"consumer": func(ctx context.Context) error { return errStuck },
+				"grpc":     nil,
+			},
+			order:     []string{"database", "consumer", "grpc"},
+			wantCalls: "stopping grpc consumer database",
+			wantErrs:  []string{"stopping consumer: stuck"},
+		},
+		{
+			name: "shared deadline",
+			stoppers: map[string]func(ctx context.Context) error{
+				"database": func(ctx context.Context) error { return ctx.Err() },
+				"grpc": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			order:     []string{"database", "grpc"},
+			wantCalls: "stopping grpc database",
+			wantErrs:  []string{"stopping grpc: context deadline exceeded", "stopping database: context deadline exceeded"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestManager(20 * time.Millisecond)
+			calls := &recorder{}
+			m.OnStopping(func() { calls.record("stopping") })
+			for _, name := range tc.order {
+				name, stop := name, tc.stoppers[name]
+				m.OnStop(name, func(ctx context.Context) error {
+					calls.record(name)
+					if stop == nil {
+						return nil
+					}
+					return stop(ctx)
+				})
+			}
+
+			err := m.Shutdown()
+			if got := calls.String(); got != tc.wantCalls {
+				t.Errorf("calls = %s, want %s", got, tc.wantCalls)
+			}
+			for _, want := range tc.wantErrs {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("Shutdown = %v, want error containing %q", err, want)
+				}
+			}
+			if len(tc.wantErrs) == 0 && err != nil {
+				t.Errorf("Shutdown = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// A failing component shuts the others down and is reported by Wait.
+func TestWaitOnFailure(t *testing.T) {
+	m := newTestManager(time.Second)
+	errListen := errors.New("address in use")
+	stopped := make(chan struct{})
+
+	m.OnStop("consumer", func(ctx context.Context) error {
+		close(stopped)
+		return nil
+	})
+	m.Go("consumer", func() error {
+		<-stopped
+		return nil
+	})
+	m.Go("grpc", func() error { return errListen })
+
+	done := make(chan error)
+	go func() { done <- m.Wait() }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errListen) || !strings.Contains(err.Error(), "grpc") {
+			t.Errorf("Wait = %v, want the grpc failure", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return")
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("consumer was not stopped")
+	}
+}
+
+// Only the first failure causes the shutdown, later ones are logged.
+func TestGoAfterFailure(t *testing.T) {
+	m := newTestManager(time.Second)
+	first := errors.New("first")
+
+	m.Go("a", func() error { return first })
+	// Wait for the failure to be queued.
+	for len(m.failures) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	returned := make(chan struct{})
+	m.Go("b", func() error {
+		defer close(returned)
+		return errors.New("second")
+	})
+	<-returned
+
+	if err := m.Wait(); !errors.Is(err, first) {
+		t.Errorf("Wait = %v, want %v", err, first)
+	}
+}
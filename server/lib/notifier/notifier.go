@@ -0,0 +1,88 @@
+// Package notifier delivers out-of-band messages such as password reset links
+// to users. The log and file notifiers are meant for local development; a mail
+// or SMS backend only needs to implement Notifier.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Message struct {
+	To      string            `json:"to"`
+	Subject string            `json:"subject"`
+	Body    string            `json:"body"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// New returns the notifier configured by kind: "log" or "file".
+func New(kind string, filePath string, logger *logrus.Logger) (Notifier, error) {
+	switch kind {
+	case "", "log":
+		return NewLogNotifier(logger), nil
+	case "file":
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+type logNotifier struct {
+	logger *logrus.Logger
+}
+
+// NewLogNotifier writes every message to the logger.
+func NewLogNotifier(logger *logrus.Logger) Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, msg Message) error {
+	n.logger.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info(msg.Body)
+
+	return nil
+}
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier appends every message as a JSON line to path.
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Notify(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,104 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		kind    string
+		wantErr bool
+	}{
+		{"", false},
+		{"log", false},
+		{"file", false},
+		{"smtp", true},
+	}
+
+	for _, tc := range tests {
+		n, err := New(tc.kind, filepath.Join(t.TempDir(), "messages.jsonl"), logrus.New())
+		if (err != nil) != tc.wantErr {
+			t.Errorf("New(%q) error = %v, want error %v", tc.kind, err, tc.wantErr)
+		}
+		if err == nil && n == nil {
+			t.Errorf("New(%q) returned no notifier", tc.kind)
+		}
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	n := NewLogNotifier(logger)
+
+	if err := n.Notify(context.Background(), Message{To: "alice@example.com", Subject: "Reset", Body: "https://example.com/reset"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("nothing logged")
+	}
+	if entry.Message != "https://example.com/reset" || entry.Data["to"] != "alice@example.com" || entry.Data["subject"] != "Reset" {
+		t.Errorf("logged %q with %v", entry.Message, entry.Data)
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	n := NewFileNotifier(path)
+
+	messages := []Message{
+		{To: "alice@example.com", Subject: "Reset", Body: "reset link", Data: map[string]string{"token": "abc"}},
+		{To: "bob@example.com", Subject: "Verify", Body: "verify link"},
+	}
+	for _, msg := range messages {
+		if err := n.Notify(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file mode %o, want 600", perm)
+	}
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for ; scanner.Scan(); lines++ {
+		var got struct {
+			Message
+			SentAt string `json:"sent_at"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("line %d: %v", lines+1, err)
+		}
+		if lines >= len(messages) {
+			continue
+		}
+
+		want := messages[lines]
+		if got.To != want.To || got.Subject != want.Subject || got.Body != want.Body || got.Data["token"] != want.Data["token"] || got.SentAt == "" {
+			t.Errorf("line %d = %+v, want %+v", lines+1, got, want)
+		}
+	}
+	if lines != len(messages) {
+		t.Errorf("%d lines, want %d", lines, len(messages))
+	}
+}
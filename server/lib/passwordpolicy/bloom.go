@@ -0,0 +1,120 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Bloom is a bloom filter of passwords. Test never misses an added password
+// and wrongly reports other passwords at about the false positive rate the
+// filter was created with, so a large corpus fits in a few bits per entry.
+type Bloom struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+	count  int
+}
+
+// NewBloom returns a filter sized for n passwords at the false positive rate
+// fpRate.
+func NewBloom(n int, fpRate float64) *Bloom {
+	if n < 1 {
+		n = 1
+	}
+
+	size := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if size < 64 {
+		size = 64
+	}
+
+	hashes := uint64(math.Round(float64(size) / float64(n) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+
+	return &Bloom{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+// LoadBloom reads a corpus of one password per line from path into a new
+// filter. Empty lines are skipped.
+func LoadBloom(path string, fpRate float64) (*Bloom, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// The first pass counts the passwords to size the filter.
+	n := 0
+	if err := scanLines(file, func(string) { n++ }); err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	b := NewBloom(n, fpRate)
+	if err := scanLines(file, b.Add); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Add adds password to the filter.
+func (b *Bloom) Add(password string) {
+	h1, h2 := bloomHashes(password)
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.count++
+}
+
+// Test reports whether password may have been added to the filter.
+func (b *Bloom) Test(password string) bool {
+	h1, h2 := bloomHashes(password)
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Len returns the number of passwords added to the filter.
+func (b *Bloom) Len() int {
+	return b.count
+}
+
+// bloomHashes derives the two hashes combined into the bit positions of
+// password.
+func bloomHashes(password string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(password))
+	// An odd step visits different bits for every i.
+	return binary.LittleEndian.Uint64(sum[0:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
+
+func scanLines(r io.Reader, fn func(string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSuffix(scanner.Text(), "\r"); line != "" {
+			fn(line)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,81 @@
+package passwordpolicy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBloom(t *testing.T) {
+	const n = 10000
+	b := NewBloom(n, 0.01)
+	for i := 0; i < n; i++ {
+		b.Add(fmt.Sprintf("password%d", i))
+	}
+
+	for i := 0; i < n; i++ {
+		if !b.Test(fmt.Sprintf("password%d", i)) {
+			t.Fatalf("password%d was added but is not found", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if b.Test(fmt.Sprintf("other%d", i)) {
+			falsePositives++
+		}
+	}
+	// Three times the configured rate leaves room for chance.
+	if rate := float64(falsePositives) / n; rate > 0.03 {
+		t.Errorf("false positive rate %.3f, want about 0.01", rate)
+	}
+	if b.Len() != n {
+		t.Errorf("Len = %d, want %d", b.Len(), n)
+	}
+}
+
+func TestLoadBloom(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		found   []string
+		wantLen int
+	}{
+		{"one per line", "123456\npassword\nqwerty\n", []string{"123456", "password", "qwerty"}, 3},
+		{"crlf and empty lines", "123456\r\n\r\npassword\r\n", []string{"123456", "password"}, 2},
+		{"no trailing newline", "letmein", []string{"letmein"}, 1},
+		{"empty", "", nil, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := LoadBloom(path, 0.001)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Len() != tc.wantLen {
+				t.Errorf("Len = %d, want %d", b.Len(), tc.wantLen)
+			}
+			for _, password := range tc.found {
+				if !b.Test(password) {
+					t.Errorf("%q not found", password)
+				}
+			}
+			if b.Test("Correct-Horse-77") {
+				t.Error("password not in the corpus found")
+			}
+		})
+	}
+
+	if _, err := LoadBloom(filepath.Join(dir, "missing"), 0.001); err == nil {
+		t.Error("LoadBloom of a missing file succeeded")
+	}
+}
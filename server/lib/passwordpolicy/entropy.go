@@ -0,0 +1,77 @@
+package passwordpolicy
+
+import (
+	"math"
+	"unicode"
+)
+
+// Entropy estimates the strength of password in bits. Every character adds
+// log2 of the size of the alphabets used in the password, except characters
+// repeating the previous one or continuing a sequence like "abc" or "321",
+// which add a single bit.
+func Entropy(password string) float64 {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	perChar := math.Log2(float64(alphabetSize(runes)))
+
+	bits := perChar
+	for i := 1; i < len(runes); i++ {
+		diff := runes[i] - runes[i-1]
+		predictable := diff == 0
+		if diff == 1 || diff == -1 {
+			// A step of one continues a sequence when the step before was the
+			// same, or when it is the second character.
+			predictable = i == 1 || runes[i-1]-runes[i-2] == diff
+		}
+
+		if predictable {
+			bits++
+		} else {
+			bits += perChar
+		}
+	}
+
+	return bits
+}
+
+// alphabetSize returns the number of characters an attacker has to try per
+// position to cover every class of character in runes.
+func alphabetSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if symbol {
+		size += 33
+	}
+	if other {
+		size += 100
+	}
+
+	return size
+}
@@ -0,0 +1,91 @@
+// Package passwordpolicy decides whether a password is acceptable for an
+// account: length, character classes, estimated strength and whether it is a
+// known breached password.
+package passwordpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy is the set of rules a new password has to satisfy. Zero values
+// disable a rule.
+type Policy struct {
+	// Minimum length in characters.
+	MinLength int
+	// Maximum length in bytes. bcrypt only uses the first 72 bytes.
+	MaxBytes int
+	// Number of character classes (lower case, upper case, digits, symbols)
+	// the password has to contain.
+	MinCharClasses int
+	// Reject passwords containing the user name, ignoring case.
+	ForbidUsername bool
+	// Minimum strength in bits as estimated by Entropy.
+	MinEntropyBits float64
+	// Known breached passwords.
+	Breached *Bloom
+}
+
+// Check returns a description of every rule password breaks, nil when it is
+// acceptable. username is the name of the account the password is for.
+func (p *Policy) Check(password, username string) []string {
+	var violations []string
+
+	if length := utf8.RuneCountInString(password); length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		violations = append(violations, fmt.Sprintf("password must not be longer than %d bytes", p.MaxBytes))
+	}
+
+	if classes := CharClasses(password); classes < p.MinCharClasses {
+		violations = append(violations, fmt.Sprintf(
+			"password must contain at least %d of lower case letters, upper case letters, digits and symbols",
+			p.MinCharClasses))
+	}
+
+	if p.ForbidUsername && username != "" &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "password must not contain the user name")
+	}
+
+	if p.MinEntropyBits > 0 && Entropy(password) < p.MinEntropyBits {
+		violations = append(violations, "password is too easy to guess")
+	}
+
+	if p.Breached != nil && p.Breached.Test(password) {
+		violations = append(violations, "password appears in a list of breached passwords")
+	}
+
+	return violations
+}
+
+// CharClasses returns how many of lower case letters, upper case letters,
+// digits and symbols password contains.
+func CharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,104 @@
+package passwordpolicy
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	breached := NewBloom(2, 0.001)
+	breached.Add("Summer2024!")
+	breached.Add("Password123!")
+
+	policy := &Policy{
+		MinLength:      8,
+		MaxBytes:       72,
+		MinCharClasses: 3,
+		ForbidUsername: true,
+		MinEntropyBits: 30,
+		Breached:       breached,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		// Words every violation is expected to contain, one per violation.
+		want []string
+	}{
+		{"acceptable", "Correct-Horse-77", "alice", nil},
+		{"too short", "Ab1-x", "alice", []string{"at least 8 characters"}},
+		{"length counts characters", "Äbc-1234", "alice", nil},
+		{"too long", "Aa1-" + strings.Repeat("x9", 40), "alice", []string{"72 bytes"}},
+		{"too few classes", "correcthorsebattery", "alice", []string{"at least 3 of"}},
+		{"contains the user name", "Xx-ALICE-99", "alice", []string{"user name"}},
+		{"user name check without name", "Xx-ALICE-99", "", nil},
+		{"predictable", "Aaaaaaaaaaaa1", "alice", []string{"too easy"}},
+		{"breached", "Password123!", "alice", []string{"breached"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := policy.Check(tc.password, tc.username)
+			if len(violations) != len(tc.want) {
+				t.Fatalf("Check = %q, want %d violations", violations, len(tc.want))
+			}
+			for i, want := range tc.want {
+				if !strings.Contains(violations[i], want) {
+					t.Errorf("violation %q does not mention %q", violations[i], want)
+				}
+			}
+		})
+	}
+
+	if violations := (&Policy{}).Check("", "alice"); violations != nil {
+		t.Errorf("zero policy rejects: %q", violations)
+	}
+}
+
+func TestCharClasses(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcDEF", 2},
+		{"abcDEF123", 3},
+		{"abcDEF123!", 4},
+		{"éÉ٣", 3},
+		{"  ", 1},
+	}
+
+	for _, tc := range tests {
+		if got := CharClasses(tc.password); got != tc.want {
+			t.Errorf("CharClasses(%q) = %d, want %d", tc.password, got, tc.want)
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	lower := math.Log2(26)
+
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{"", 0},
+		{"a", lower},
+		{"az", 2 * lower},
+		{"aaaa", lower + 3},
+		{"abcd", lower + 3},
+		{"dcba", lower + 3},
+		{"abd", 2*lower + 1},
+		{"aB", 2 * math.Log2(52)},
+		{"a1!", 3 * math.Log2(26+10+33)},
+	}
+
+	for _, tc := range tests {
+		if got := Entropy(tc.password); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Entropy(%q) = %.2f, want %.2f", tc.password, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package throttle implements progressive back-off for failed attempts.
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Policy describes how long a key is blocked after consecutive failures.
+// The first FreeAttempts failures do not block. Every further failure blocks
+// for BackoffBase doubled per previous blocking failure, capped at BackoffMax.
+// Once LockoutThreshold failures are reached the key is locked for
+// LockoutDuration. Failures older than LockoutDuration are forgotten.
+type Policy struct {
+	FreeAttempts     int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// Delay returns how long to block after the given number of consecutive failures.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	if p.LockoutThreshold > 0 && failures >= p.LockoutThreshold {
+		return p.LockoutDuration
+	}
+
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BackoffBase
+	for i := p.FreeAttempts + 1; i < failures && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > p.BackoffMax {
+		delay = p.BackoffMax
+	}
+
+	return delay
+}
+
+// Expired reports whether a failure recorded at lastFailure no longer counts.
+func (p Policy) Expired(lastFailure time.Time, now time.Time) bool {
+	return now.Sub(lastFailure) > p.LockoutDuration
+}
+
+type entry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// Limiter tracks failures per key in memory, e.g. per client IP.
+type Limiter struct {
+	mu      sync.Mutex
+	policy  Policy
+	entries map[string]*entry
+	swept   time.Time
+}
+
+func NewLimiter(policy Policy) *Limiter {
+	return &Limiter{
+		policy:  policy,
+		entries: map[string]*entry{},
+	}
+}
+
+// SetPolicy replaces the policy. Failures already recorded are kept and count
+// towards the new policy.
+func (l *Limiter) SetPolicy(policy Policy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.policy = policy
+}
+
+// Check returns how long key is still blocked, zero when it may proceed.
+func (l *Limiter) Check(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return 0
+	}
+
+	if wait := time.Until(e.blockedUntil); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// Failures returns the number of consecutive failures of key still counted.
+func (l *Limiter) Failures(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok || l.policy.Expired(e.lastFailure, time.Now()) {
+		return 0
+	}
+
+	return e.failures
+}
+
+// Failure records a failed attempt and returns how long key is now blocked.
+func (l *Limiter) Failure(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	e, ok := l.entries[key]
+	if !ok || l.policy.Expired(e.lastFailure, now) {
+		e = &entry{}
+		l.entries[key] = e
+	}
+
+	e.failures++
+	e.lastFailure = now
+
+	delay := l.policy.Delay(e.failures)
+	e.blockedUntil = now.Add(delay)
+
+	return delay
+}
+
+// Reset forgets all failures of key.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+}
+
+// sweep drops expired entries so the map does not grow without bound.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.policy.LockoutDuration {
+		return
+	}
+
+	for key, e := range l.entries {
+		if l.policy.Expired(e.lastFailure, now) && now.After(e.blockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+	l.swept = now
+}
@@ -0,0 +1,74 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:     2,
+		BackoffBase:      time.Second,
+		BackoffMax:       5 * time.Second,
+		LockoutThreshold: 8,
+		LockoutDuration:  time.Hour,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 5 * time.Second},
+		{7, 5 * time.Second},
+		{8, time.Hour},
+		{9, time.Hour},
+	}
+
+	for _, tc := range tests {
+		if got := policy.Delay(tc.failures); got != tc.want {
+			t.Errorf("Delay(%d) = %s, want %s", tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(Policy{
+		FreeAttempts:     1,
+		BackoffBase:      time.Hour,
+		BackoffMax:       time.Hour,
+		LockoutThreshold: 3,
+		LockoutDuration:  time.Hour,
+	})
+
+	tests := []struct {
+		name         string
+		action       func()
+		wantFailures int
+		wantBlocked  bool
+	}{
+		{"unknown key", func() {}, 0, false},
+		{"free failure", func() { l.Failure("a") }, 1, false},
+		{"backing off", func() { l.Failure("a") }, 2, true},
+		{"other key", func() { l.Failure("b") }, 2, true},
+		{"locked", func() { l.Failure("a") }, 3, true},
+		{"reset", func() { l.Reset("a") }, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.action()
+			if got := l.Failures("a"); got != tc.wantFailures {
+				t.Errorf("Failures = %d, want %d", got, tc.wantFailures)
+			}
+			if blocked := l.Check("a") > 0; blocked != tc.wantBlocked {
+				t.Errorf("blocked = %v, want %v", blocked, tc.wantBlocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA1 secret of the RFC 6238 test vectors, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The codes are the last six digits of the RFC 6238 appendix B values.
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		if err != nil || got != tc.want {
+			t.Errorf("Code at %d = %s, %v, want %s", tc.unix, got, err, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	code := func(step int64) string {
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcSecret, code(current), 0, current, true},
+		{"surrounding spaces", rfcSecret, " " + code(current) + "\n", 0, current, true},
+		{"lower case secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code(current), 0, current, true},
+		{"previous step within skew", rfcSecret, code(current - 1), 1, current - 1, true},
+		{"next step within skew", rfcSecret, code(current + 1), 1, current + 1, true},
+		{"previous step without skew", rfcSecret, code(current - 1), 0, 0, false},
+		{"step beyond skew", rfcSecret, code(current + 2), 1, 0, false},
+		{"wrong code", rfcSecret, "000000", 1, 0, false},
+		{"too short", rfcSecret, code(current)[:5], 1, 0, false},
+		{"too long", rfcSecret, code(current) + "0", 1, 0, false},
+		{"invalid secret", "not base32!", code(current), 1, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			step, ok := Validate(tc.secret, tc.code, now, tc.skew)
+			if ok != tc.wantOK || step != tc.wantStep {
+				t.Errorf("Validate = %d, %v, want %d, %v", step, ok, tc.wantStep, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	first, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Error("two secrets are equal")
+	}
+	if key, err := encoding.DecodeString(first); err != nil || len(key) != secretSize {
+		t.Errorf("secret %q decodes to %d bytes, %v", first, len(key), err)
+	}
+	if _, err := Code(first, 1); err != nil {
+		t.Errorf("Code with generated secret: %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	tests := []struct {
+		name      string
+		issuer    string
+		account   string
+		wantLabel string
+	}{
+		{"plain", "go-auth", "alice", "go-auth:alice"},
+		{"spaces", "Go Auth", "alice smith", "Go%20Auth:alice%20smith"},
+		{"reserved characters", "go-auth", "a/b?c", "go-auth:a%2Fb%3Fc"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			uri := URI(tc.issuer, tc.account, rfcSecret)
+
+			parsed, err := url.Parse(uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Authenticator apps read + literally.
+			if parsed.Scheme != "otpauth" || parsed.Host != "totp" || strings.Contains(uri, "+") {
+				t.Errorf("uri = %s", uri)
+			}
+			if label := parsed.EscapedPath(); label != "/"+tc.wantLabel {
+				t.Errorf("label = %s, want /%s", label, tc.wantLabel)
+			}
+
+			query := parsed.Query()
+			want := map[string]string{"secret": rfcSecret, "issuer": tc.issuer, "algorithm": "SHA1", "digits": "6", "period": "30"}
+			for key, value := range want {
+				if query.Get(key) != value {
+					t.Errorf("%s = %q, want %q", key, query.Get(key), value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package ulid generates ULIDs, 128-bit identifiers made of a millisecond
+// timestamp and 80 random bits, written as 26 characters of Crockford's
+// base32. They sort by creation time but cannot be guessed or enumerated.
+package ulid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"time"
+)
+
+// Length of an encoded ULID.
+const Length = 26
+
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// New returns a new ULID for the current time.
+func New() (string, error) {
+	return NewAt(time.Now())
+}
+
+// NewAt returns a new ULID for t.
+func NewAt(t time.Time) (string, error) {
+	var id [16]byte
+
+	// The timestamp fills the first 48 bits, the rest is random.
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(id[:6], ms[2:])
+
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+
+	return encode(id), nil
+}
+
+// Valid reports whether s is an encoded ULID.
+func Valid(s string) bool {
+	if len(s) != Length || s[0] > '7' {
+		// The first character only holds the top 3 of 128 bits.
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if indexOf(s[i]) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func encode(id [16]byte) string {
+	n := new(big.Int).SetBytes(id[:])
+	mask := big.NewInt(31)
+
+	out := make([]byte, Length)
+	for i := Length - 1; i >= 0; i-- {
+		out[i] = alphabet[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+
+	return string(out)
+}
+
+func indexOf(c byte) int {
+	for i := 0; i < len(alphabet); i++ {
+		if alphabet[i] == c {
+			return i
+		}
+	}
+
+	return -1
+}
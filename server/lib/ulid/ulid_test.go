@@ -0,0 +1,85 @@
+package ulid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAt(t *testing.T) {
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantPrefix string
+	}{
+		{"epoch", time.UnixMilli(0), "0000000000"},
+		{"one millisecond", time.UnixMilli(1), "0000000001"},
+		{"32 milliseconds", time.UnixMilli(32), "0000000010"},
+		{"largest time", time.UnixMilli(1<<48 - 1), "7ZZZZZZZZZ"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := NewAt(tc.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(id, tc.wantPrefix) || !Valid(id) {
+				t.Errorf("NewAt = %s, want valid ULID starting with %s", id, tc.wantPrefix)
+			}
+		})
+	}
+}
+
+// ULIDs of later times sort after earlier ones, whatever their random part.
+func TestNewAtSorts(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	previous := ""
+	for i := 0; i < 100; i++ {
+		id, err := NewAt(start.Add(time.Duration(i) * time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= previous {
+			t.Fatalf("%s sorts before %s", id, previous)
+		}
+		previous = id
+	}
+}
+
+func TestNewIsRandom(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[id] {
+			t.Fatalf("%s generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", true},
+		{"7ZZZZZZZZZZZZZZZZZZZZZZZZZ", true},
+		{"8ZZZZZZZZZZZZZZZZZZZZZZZZZ", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FA", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAVX", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAI", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAU", false},
+		{"01arz3ndektsv4rrffq69g5fav", false},
+		{"", false},
+	}
+
+	for _, tc := range tests {
+		if got := Valid(tc.id); got != tc.want {
+			t.Errorf("Valid(%q) = %v, want %v", tc.id, got, tc.want)
+		}
+	}
+}
@@ -1,16 +1,35 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
 	"go-auth/server/api"
 	"go-auth/server/config"
+	"go-auth/server/database"
+	"go-auth/server/gateway"
 	manager "go-auth/server/jwt"
+	"go-auth/server/lib/actiontoken"
+	"go-auth/server/lib/asynchook"
+	"go-auth/server/lib/cors"
+	"go-auth/server/lib/encryption"
+	"go-auth/server/lib/hasher"
+	"go-auth/server/lib/healthcheck"
+	"go-auth/server/lib/lifecycle"
+	"go-auth/server/lib/notifier"
 	"go-auth/server/lib/rabbitmq"
+	"go-auth/server/lib/throttle"
+	"go-auth/server/migrations"
 	"go-auth/server/models"
 	"go-auth/server/pb"
+	"go-auth/server/repository"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
 
-	"go-auth/www/docs"
+	_ "go-auth/www/docs"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -22,107 +41,439 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.elastic.co/ecslogrus"
 	"google.golang.org/grpc"
-	"gorm.io/driver/mysql"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	"gorm.io/gorm"
 )
 
 const serviceName = "go-auth-service"
-const defaultPort = 50051
-
-var appConfig *config.Config
 
 func main() {
+	// Flags come before the subcommand, e.g. "-config app.yaml migrate up".
+	configOptions := config.Options{Args: os.Args[1:]}
+	appConfig, args, err := config.Load(configOptions)
+	if err != nil {
+		logrus.Fatalf("invalid configuration: %v", err)
+	}
+
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(appConfig, args[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
 
-	appConfig = config.InitConfig(".env")
+	if err := appConfig.Validate(); err != nil {
+		logrus.Fatalf("invalid configuration: %v", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		db, err := openDatabase(appConfig)
+		if err != nil {
+			logrus.Fatalf("failed to connect database: %v", err)
+		}
+		if err := runMigrate(db, appConfig.AppKey, args[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
 
-	docs.SwaggerInfo.Title = "Go Auth API"
-	docs.SwaggerInfo.Description = "API documentation for Go Auth Service"
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Host = "localhost:8080"
-	docs.SwaggerInfo.BasePath = "/"
+	liveConfig := config.NewLive(appConfig)
 
 	esLogger := logrus.New()
 	esLogger.SetFormatter(&ecslogrus.Formatter{})
-	esLogger.SetLevel(logrus.InfoLevel)
+	setLogLevel(esLogger, appConfig)
 
-	client, err := elastic.NewClient(elastic.SetURL("http://localhost:9200"), elastic.SetSniff(false))
+	client, err := elastic.NewClient(elastic.SetURL(appConfig.ElasticsearchUrl), elastic.SetSniff(false))
 	if err != nil {
 		esLogger.Fatalf("Failed to create elasticsearch client: %v", err)
 	}
 
-	hook, err := elasticLog.NewAsyncElasticHook(client, serviceName, logrus.InfoLevel, "go-auth-logs")
+	esHook, err := elasticLog.NewElasticHook(client, serviceName, logrus.InfoLevel, "go-auth-logs")
 	if err != nil {
 		logrus.Fatalf("Failed to create Elasticsearch hook: %v", err)
 	}
+	hook := asynchook.New(esHook)
 	esLogger.AddHook(hook)
 
-	dsn := "go_user:go_password@tcp(127.0.0.1:3306)/go_auth?charset=utf8mb4&parseTime=True&loc=Local"
+	esLogger.Info("Configuration: ", appConfig.AsString())
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// Components register how they stop as they are created and are stopped
+	// in reverse order, see lifecycle.Manager.OnStop.
+	app := lifecycle.New(appConfig.ShutdownTimeout, esLogger)
+	app.OnStop("log shipping", func(ctx context.Context) error {
+		err := hook.Flush(ctx)
+		// Abort the requests still running after the deadline.
+		esHook.Cancel()
+		return err
+	})
+
+	db, err := openDatabase(appConfig)
 	if err != nil {
 		esLogger.Fatalf("failed to connect database: %v", err)
 	}
 
-	db.AutoMigrate(&models.User{})
+	app.OnStop("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	// The schema is changed by the migrate command only, never on startup.
+	if err := migrations.New(db, appConfig.AppKey).Check(); err != nil {
+		esLogger.Fatalf("refusing to start: %v", err)
+	}
+
+	transactor := repository.NewGormTransactor(db)
+	users := repository.NewGormUserRepository(db)
+	roles := repository.NewGormRoleRepository(db)
 
-	lis, err := net.Listen("tcp", ":50051")
+	if err := api.SeedRoles(transactor, users, roles, appConfig.AdminUsers); err != nil {
+		esLogger.Fatalf("failed to seed roles: %v", err)
+	}
+
+	grpcListeners, err := listen(appConfig.GrpcAddresses())
+	if err != nil {
+		esLogger.Fatalf("failed to listen: %v", err)
+	}
+	httpListeners, err := listen(appConfig.HttpAddresses())
 	if err != nil {
 		esLogger.Fatalf("failed to listen: %v", err)
 	}
 
-	rabbitmq.InitRabbitMq()
+	rabbitmq.InitRabbitMq(appConfig.RabbitMqUrl)
 
-	// Start the RabbitMQ consumer in a goroutine
-	go rabbitmq.StartConsumer("orders")
+	consumerCtx, cancelConsumer := context.WithCancel(context.Background())
+	consumerDone := make(chan struct{})
+	app.Go("RabbitMQ consumer", func() error {
+		defer close(consumerDone)
+		return rabbitmq.StartConsumer(consumerCtx, appConfig.RabbitMqUrl, "orders")
+	})
+	app.OnStop("RabbitMQ consumer", func(ctx context.Context) error {
+		cancelConsumer()
+		select {
+		case <-consumerDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	keyRing := manager.NewHMACKeyRing(appConfig.AppKey)
+	if appConfig.JwtSigningKeyFile != "" {
+		keyRing, err = manager.LoadKeyRing(appConfig.JwtSigningKeyFile, appConfig.JwtVerificationKeyFiles)
+		if err != nil {
+			esLogger.Fatalf("failed to load jwt keys: %v", err)
+		}
+	}
+
+	jwtManager := manager.NewJWTManager(
+		keyRing,
+		appConfig.AccessTokenDuration,
+		appConfig.RefreshTokenDuration,
+		manager.NewGormRefreshTokenStore(db),
+		manager.NewGormRevocationStore(db, appConfig.RevocationSyncInterval),
+		manager.NewGormAuthorityResolver(db),
+		esLogger,
+	)
+	authInterceptor := manager.NewAuthInterceptor(jwtManager, api.MethodPolicies(), esLogger)
+
+	errorInterceptor := api.NewErrorInterceptor(esLogger)
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(errorInterceptor.Unary(), authInterceptor.Unary()),
+		grpc.ChainStreamInterceptor(errorInterceptor.Stream(), authInterceptor.Stream()),
+	)
+
+	healthChecker := healthcheck.New(health.NewServer(), appConfig.HealthCheckTimeout, esLogger,
+		pb.UserService_ServiceDesc.ServiceName, pb.AdminService_ServiceDesc.ServiceName)
+	healthgrpc.RegisterHealthServer(grpcServer, healthChecker.Server())
+	healthChecker.Add(healthcheck.Check{Name: "database", Critical: true, Fn: func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}})
+	healthChecker.Add(healthcheck.Check{Name: "rabbitmq", Fn: func(ctx context.Context) error {
+		return rabbitmq.Ping(ctx, appConfig.RabbitMqUrl)
+	}})
+	healthChecker.Add(healthcheck.Check{Name: "elasticsearch", Fn: func(ctx context.Context) error {
+		clusterHealth, err := client.ClusterHealth().Do(ctx)
+		if err != nil {
+			return err
+		}
+		if clusterHealth.Status == "red" {
+			return errors.New("cluster status is red")
+		}
+		return nil
+	}})
+
+	healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+	go healthChecker.Run(healthCtx, appConfig.HealthCheckInterval)
+	app.OnStop("health checks", func(ctx context.Context) error {
+		stopHealthChecks()
+		return nil
+	})
+	// Clients and load balancers see NOT_SERVING before the servers stop.
+	app.OnStopping(healthChecker.Shutdown)
+	userNotifier, err := notifier.New(appConfig.Notifier, appConfig.NotifierFilePath, esLogger)
+	if err != nil {
+		esLogger.Fatalf("failed to create notifier: %v", err)
+	}
+
+	passwordHashers, err := api.PasswordHashers(appConfig)
+	if err != nil {
+		esLogger.Fatalf("failed to configure password hashing: %v", err)
+	}
+	// Logins of unknown users verify against this hash, create it before the
+	// first one so it does not add to their response time.
+	if _, err := passwordHashers.DummyHash(); err != nil {
+		esLogger.Fatalf("failed to configure password hashing: %v", err)
+	}
+
+	passwordPolicy, err := api.PasswordPolicy(appConfig)
+	if err != nil {
+		esLogger.Fatalf("failed to configure password policy: %v", err)
+	}
+	if passwordPolicy.Breached != nil {
+		esLogger.Infof("Loaded %d breached passwords", passwordPolicy.Breached.Len())
+	}
+
+	hashPool := hasher.NewPool(appConfig.HashWorkers, appConfig.HashQueueSize)
+	hashPool.Publish("password_hashing")
+
+	mfaKey, err := encryption.NewKey(appConfig.AppKey, models.MfaSecretPurpose)
+	if err != nil {
+		esLogger.Fatalf("failed to derive mfa secret key: %v", err)
+	}
 
-	grpcServer := grpc.NewServer()
 	userService := &api.Server{
-		Db:      db,
-		Logger:  esLogger,
-		Manager: manager.NewJWTManager(appConfig.AppKey, 3600, esLogger),
+		Transactor: transactor,
+		Users:      users,
+		Roles:      roles,
+		Logger:     esLogger,
+		Manager:    jwtManager,
+		Config:     liveConfig,
+
+		LoginThrottle:         throttle.NewLimiter(api.ClientLoginPolicy(appConfig)),
+		UnknownNameThrottle:   throttle.NewLimiter(api.AccountLoginPolicy(appConfig)),
+		MessageClientThrottle: throttle.NewLimiter(api.ClientLoginPolicy(appConfig)),
+		MessageNameThrottle:   throttle.NewLimiter(api.AccountLoginPolicy(appConfig)),
+		Notifier:              userNotifier,
+		ActionTokens:          actiontoken.NewManager(db, []byte(appConfig.AppKey)),
+		MfaKey:                mfaKey,
+		Passwords:             passwordHashers,
+		HashPool:              hashPool,
+		PasswordPolicy:        passwordPolicy,
 	}
 
+	corsHandler := cors.New(corsPolicy(appConfig))
+
+	reloader := config.NewReloader(liveConfig, configOptions, esLogger)
+	reloader.OnReload(func(c *config.Config) {
+		setLogLevel(esLogger, c)
+		corsHandler.SetPolicy(corsPolicy(c))
+		userService.LoginThrottle.SetPolicy(api.ClientLoginPolicy(c))
+		userService.UnknownNameThrottle.SetPolicy(api.AccountLoginPolicy(c))
+		userService.MessageClientThrottle.SetPolicy(api.ClientLoginPolicy(c))
+		userService.MessageNameThrottle.SetPolicy(api.AccountLoginPolicy(c))
+		jwtManager.SetDurations(c.AccessTokenDuration, c.RefreshTokenDuration)
+	})
+	reloadCtx, stopReloading := context.WithCancel(context.Background())
+	go func() {
+		if err := reloader.Run(reloadCtx); err != nil {
+			esLogger.Error("Configuration reloading stopped: ", err)
+		}
+	}()
+	app.OnStop("config reloader", func(ctx context.Context) error {
+		stopReloading()
+		return nil
+	})
+
 	router := gin.Default()
+	router.Use(corsHandler.Middleware())
 
 	// Route to serve the Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Register API endpoints
-	router.POST("/login", loginUser(userService))
+	router.GET("/.well-known/jwks.json", jwks(userService))
+	router.GET("/healthz", healthChecker.Liveness())
+	router.GET("/readyz", healthChecker.Readiness())
+
+	// Every UserService RPC is served over REST by the gateway, see the HTTP
+	// annotations in proto/go_auth_api.proto.
+	// Canceling gatewayCtx closes the connection of the gateway to gRPC.
+	gatewayCtx, closeGateway := context.WithCancel(context.Background())
+	restGateway, err := gateway.New(gatewayCtx, gatewayEndpoint(grpcListeners))
+	if err != nil {
+		esLogger.Fatalf("failed to create REST gateway: %v", err)
+	}
+	router.NoRoute(gin.WrapH(restGateway))
 
-	go router.Run(":8080")
 	pb.RegisterUserServiceServer(grpcServer, userService)
+	pb.RegisterAdminServiceServer(grpcServer, userService)
 
-	if err := grpcServer.Serve(lis); err != nil {
-		esLogger.Fatalf("failed to serve: %v", err)
+	for _, lis := range grpcListeners {
+		lis := lis
+		esLogger.Info("gRPC server listening on ", lis.Addr())
+		app.Go("gRPC server", func() error {
+			if err := grpcServer.Serve(lis); err != grpc.ErrServerStopped {
+				return err
+			}
+			return nil
+		})
 	}
-}
+	// Stopped after the HTTP server, whose gateway calls still reach it.
+	app.OnStop("gRPC server", func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
 
-// @Summary Login User
-// @Description Authenticates a user and returns a token
-// @Tags Auth
-// @Accept  json
-// @Produce  json
-// @Param   requestBody body pb.LoginUserRequest true "Login Request"
-// @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Router /login [post]
-func loginUser(userService *api.Server) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var credentials pb.LoginUserRequest
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			// Cancels the calls still running.
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
 
-		if err := c.ShouldBindJSON(&credentials); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
-			return
+	// The metrics are not on the public router, they are only reachable
+	// from the host.
+	if appConfig.MetricsAddress != "" {
+		metricsListener, err := net.Listen("tcp", appConfig.MetricsAddress)
+		if err != nil {
+			esLogger.Fatalf("failed to listen: %v", err)
 		}
 
-		// Call userService's login method and handle response
-		resp, err := userService.LoginUser(c.Request.Context(), &credentials)
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", metricsHandler("password_hashing"))
+		metricsServer := &http.Server{Handler: mux}
+		esLogger.Info("Metrics listening on ", metricsListener.Addr())
+		app.Go("metrics server", func() error {
+			if err := metricsServer.Serve(metricsListener); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+		app.OnStop("metrics server", func(ctx context.Context) error {
+			return metricsServer.Shutdown(ctx)
+		})
+	}
+
+	httpServer := &http.Server{Handler: router}
+	for _, lis := range httpListeners {
+		lis := lis
+		esLogger.Info("HTTP server listening on ", lis.Addr())
+		app.Go("HTTP server", func() error {
+			if err := httpServer.Serve(lis); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+	}
+	app.OnStop("HTTP server", func(ctx context.Context) error {
+		defer closeGateway()
+		return httpServer.Shutdown(ctx)
+	})
+
+	if err := app.Wait(); err != nil {
+		esLogger.Error("Shutdown: ", err)
+		os.Exit(1)
+	}
+	esLogger.Info("Shutdown complete")
+}
+
+// listen binds every address, so a busy or unknown one stops the service
+// before it serves on the others.
+func listen(addresses []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addresses))
+	for _, address := range addresses {
+		lis, err := net.Listen("tcp", address)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
-			return
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, lis)
+	}
+
+	return listeners, nil
+}
+
+// gatewayEndpoint returns the address the REST gateway dials to reach the
+// gRPC server. Loopback is preferred, the gRPC server only trusts
+// X-Forwarded-For from loopback peers.
+func gatewayEndpoint(grpcListeners []net.Listener) string {
+	for _, lis := range grpcListeners {
+		addr := lis.Addr().(*net.TCPAddr)
+		if addr.IP.IsLoopback() || addr.IP.IsUnspecified() {
+			return net.JoinHostPort("localhost", strconv.Itoa(addr.Port))
+		}
+	}
+
+	return grpcListeners[0].Addr().String()
+}
+
+// metricsHandler serves the named expvar variables as one JSON object. Unlike
+// expvar.Handler it leaves out cmdline and memstats.
+func metricsHandler(names ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, "{")
+		for i, name := range names {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			value := "null"
+			if v := expvar.Get(name); v != nil {
+				value = v.String()
+			}
+			fmt.Fprintf(w, "%q: %s", name, value)
 		}
+		fmt.Fprint(w, "}")
+	})
+}
+
+// corsPolicy returns the CORS settings of c. Retry-After is exposed so pages
+// can tell how long a throttled login waits.
+func corsPolicy(c *config.Config) cors.Policy {
+	return cors.Policy{
+		AllowedOrigins:   c.CorsAllowedOrigins,
+		AllowedMethods:   c.CorsAllowedMethods,
+		AllowedHeaders:   c.CorsAllowedHeaders,
+		ExposedHeaders:   []string{"Retry-After"},
+		AllowCredentials: c.CorsAllowCredentials,
+		MaxAge:           c.CorsMaxAge,
+	}
+}
+
+// setLogLevel applies the validated LOG_LEVEL of c.
+func setLogLevel(logger *logrus.Logger, c *config.Config) {
+	level, _ := logrus.ParseLevel(c.LogLevel)
+	logger.SetLevel(level)
+}
 
-		c.JSON(http.StatusOK, gin.H{"message": "User logged in successfully", "token": resp.AccessToken})
+func openDatabase(c *config.Config) (*gorm.DB, error) {
+	dsn := c.DatabaseDsn
+	if dsn == "" {
+		dsn = database.DefaultDSN(c.DatabaseDriver)
+	}
+
+	return database.Open(c.DatabaseDriver, dsn)
+}
+
+// jwks serves the public keys used to verify issued tokens.
+func jwks(userService *api.Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, userService.Manager.JWKS())
 	}
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go-auth/server/migrations"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate runs the migrate subcommand:
+//
+//	migrate up            apply every pending migration
+//	migrate down [steps]  revert the last steps migrations, 1 by default
+//	migrate status        list migrations and when they were applied
+func runMigrate(db *gorm.DB, appKey string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator := migrations.New(db, appKey)
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, migration := range done {
+			fmt.Printf("applied %d %s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+
+		done, err := migrator.Down(steps)
+		for _, migration := range done {
+			fmt.Printf("reverted %d %s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Unknown {
+				appliedAt += " (unknown to this build)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
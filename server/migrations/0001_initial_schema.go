@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// initialSchema creates the tables previously created by AutoMigrate on
+// startup. Databases created that way already match it, so applying it to them
+// only records the version.
+//
+// The models are copied as they were at this version, so later changes of
+// package models do not change this migration. They keep the names of the
+// models because gorm derives table and constraint names from them.
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial_schema",
+	Up: func(tx *gorm.DB) error {
+		type permission struct {
+			gorm.Model
+			Name        string `gorm:"size:128;uniqueIndex"`
+			Description string
+		}
+
+		type role struct {
+			gorm.Model
+			Name        string `gorm:"size:64;uniqueIndex"`
+			Description string
+			Permissions []permission `gorm:"many2many:role_permissions"`
+		}
+
+		type user struct {
+			gorm.Model
+			Id        int `gorm:"uniqueIndex"`
+			Name      string
+			Password  string
+			CreatedAt time.Time
+			UpdatedAt time.Time
+			Roles     []role `gorm:"many2many:user_roles"`
+
+			Status string `gorm:"size:32;default:active;index"`
+
+			Email           *string `gorm:"size:254;uniqueIndex"`
+			EmailVerifiedAt *time.Time
+			DisplayName     string `gorm:"size:100"`
+			AvatarUrl       string `gorm:"size:2048"`
+			Locale          string `gorm:"size:35"`
+			Timezone        string `gorm:"size:64"`
+			Phone           string `gorm:"size:16"`
+
+			MfaSecret   string
+			MfaEnabled  bool
+			MfaLastStep int64
+
+			FailedLoginCount  int
+			LastFailedLoginAt *time.Time
+			LockedUntil       *time.Time
+		}
+
+		type refreshToken struct {
+			gorm.Model
+			Jti       string `gorm:"size:64;uniqueIndex"`
+			FamilyId  string `gorm:"size:64;index"`
+			UserId    string `gorm:"size:64;index"`
+			ExpiresAt time.Time
+			UsedAt    *time.Time
+			RevokedAt *time.Time
+		}
+
+		type revokedToken struct {
+			gorm.Model
+			Jti       string    `gorm:"size:64;uniqueIndex"`
+			UserId    string    `gorm:"size:64;index"`
+			ExpiresAt time.Time `gorm:"index"`
+		}
+
+		type recoveryCode struct {
+			gorm.Model
+			UserId   uint   `gorm:"index"`
+			CodeHash string `gorm:"size:64;uniqueIndex"`
+			UsedAt   *time.Time
+		}
+
+		type actionToken struct {
+			gorm.Model
+			Purpose    string `gorm:"size:64;index:idx_action_tokens_subject"`
+			Subject    string `gorm:"size:320;index:idx_action_tokens_subject"`
+			TokenHash  string `gorm:"size:64;uniqueIndex"`
+			ExpiresAt  time.Time
+			ConsumedAt *time.Time
+		}
+
+		return tx.AutoMigrate(
+			&user{},
+			&role{},
+			&permission{},
+			&refreshToken{},
+			&revokedToken{},
+			&recoveryCode{},
+			&actionToken{},
+		)
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(
+			"user_roles",
+			"role_permissions",
+			"users",
+			"roles",
+			"permissions",
+			"refresh_tokens",
+			"revoked_tokens",
+			"recovery_codes",
+			"action_tokens",
+		)
+	},
+}
@@ -0,0 +1,180 @@
+package migrations
+
+import (
+	"fmt"
+	"go-auth/server/lib/ulid"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// userPublicId gives every user a public id, which replaces the internal id as
+// the subject of tokens, and makes user names unique.
+//
+// Stored refresh and revoked tokens are moved to the public id. Tokens issued
+// before the migration still carry the internal id, so users have to log in
+// again once it is applied.
+//
+// Soft deleted users sharing their name with another user are renamed to
+// "<name>~deleted~<id>". The migration fails when active users share a name,
+// those have to be renamed by hand first.
+var userPublicId = Migration{
+	Version: 2,
+	Name:    "user_public_id",
+	Up: func(tx *gorm.DB) error {
+		type user struct {
+			gorm.Model
+			PublicId string `gorm:"size:26;uniqueIndex"`
+			Name     string `gorm:"size:191;uniqueIndex"`
+		}
+
+		migrator := tx.Migrator()
+
+		if !migrator.HasColumn(&user{}, "PublicId") {
+			if err := migrator.AddColumn(&user{}, "PublicId"); err != nil {
+				return err
+			}
+		}
+
+		var users []user
+		err := tx.Unscoped().Where("public_id IS NULL OR public_id = ''").FindInBatches(&users, 500, func(batch *gorm.DB, _ int) error {
+			for _, u := range users {
+				publicID, err := ulid.NewAt(u.CreatedAt)
+				if err != nil {
+					return err
+				}
+
+				if err := tx.Model(&user{}).Unscoped().Where("id = ?", u.ID).Update("public_id", publicID).Error; err != nil {
+					return err
+				}
+				if err := moveTokenSubjects(tx, strconv.FormatUint(uint64(u.ID), 10), publicID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := renameDuplicateUsers(tx); err != nil {
+			return err
+		}
+
+		// The name was unlimited text, which cannot be indexed. SQLite ignores
+		// the size and would rebuild the table, dropping its other indexes.
+		if tx.Dialector.Name() != "sqlite" {
+			if err := migrator.AlterColumn(&user{}, "Name"); err != nil {
+				return err
+			}
+		}
+
+		for _, index := range []string{"PublicId", "Name"} {
+			if !migrator.HasIndex(&user{}, index) {
+				if err := migrator.CreateIndex(&user{}, index); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Left by the duplicate Id field of the old model, the primary key
+		// already covers it.
+		if migrator.HasIndex(&user{}, "idx_users_id") {
+			return migrator.DropIndex(&user{}, "idx_users_id")
+		}
+
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		type user struct {
+			gorm.Model
+			PublicId string
+		}
+
+		var users []user
+		err := tx.Unscoped().FindInBatches(&users, 500, func(batch *gorm.DB, _ int) error {
+			for _, u := range users {
+				if err := moveTokenSubjects(tx, u.PublicId, strconv.FormatUint(uint64(u.ID), 10)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		migrator := tx.Migrator()
+		for _, index := range []string{"idx_users_name", "idx_users_public_id"} {
+			if migrator.HasIndex(&user{}, index) {
+				if err := migrator.DropIndex(&user{}, index); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := migrator.DropColumn(&user{}, "PublicId"); err != nil {
+			return err
+		}
+
+		return tx.Exec("CREATE UNIQUE INDEX idx_users_id ON users(id)").Error
+	},
+}
+
+// moveTokenSubjects changes the user of stored refresh and revoked tokens.
+func moveTokenSubjects(tx *gorm.DB, from, to string) error {
+	for _, table := range []string{"refresh_tokens", "revoked_tokens"} {
+		if err := tx.Table(table).Where("user_id = ?", from).Update("user_id", to).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameDuplicateUsers renames soft deleted users whose name is taken by
+// another user. When only soft deleted users share a name the newest keeps it.
+func renameDuplicateUsers(tx *gorm.DB) error {
+	var names []string
+	if err := tx.Table("users").Select("name").Group("name").Having("COUNT(*) > 1").Pluck("name", &names).Error; err != nil {
+		return err
+	}
+
+	var active []string
+	for _, name := range names {
+		var rows []struct {
+			ID      uint
+			Deleted bool
+		}
+		err := tx.Table("users").
+			Select("id, deleted_at IS NOT NULL AS deleted").
+			Where("name = ?", name).
+			Order("deleted_at IS NULL DESC, id DESC").
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		// The first row keeps the name: the active user, or the newest one
+		// when all are deleted.
+		for _, row := range rows[1:] {
+			if !row.Deleted {
+				active = append(active, name)
+				break
+			}
+
+			err := tx.Table("users").Where("id = ?", row.ID).
+				Update("name", fmt.Sprintf("%s~deleted~%d", name, row.ID)).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(active) > 0 {
+		return fmt.Errorf("active users share the names %s, rename them before migrating", strings.Join(active, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// revokedSessions adds the table recording, per user, when all sessions were
+// ended, so access tokens issued before are rejected.
+var revokedSessions = Migration{
+	Version: 3,
+	Name:    "revoked_sessions",
+	Up: func(tx *gorm.DB) error {
+		type revokedSession struct {
+			gorm.Model
+			UserId        string `gorm:"size:64;uniqueIndex"`
+			RevokedBefore time.Time
+			ExpiresAt     time.Time `gorm:"index"`
+		}
+
+		return tx.AutoMigrate(&revokedSession{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("revoked_sessions")
+	},
+}
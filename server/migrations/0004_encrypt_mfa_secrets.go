@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"go-auth/server/lib/encryption"
+
+	"gorm.io/gorm"
+)
+
+// encryptMfaSecrets encrypts the MFA secrets stored in plaintext with a key
+// derived from appKey. Down writes them back in plaintext.
+func encryptMfaSecrets(appKey string) Migration {
+	// Purpose of the key when the migration was written, see
+	// models.MfaSecretPurpose.
+	const purpose = "mfa secret"
+
+	type user struct {
+		ID        uint
+		MfaSecret string
+	}
+
+	rewrite := func(tx *gorm.DB, convert func(key *encryption.Key, secret string) (string, bool, error)) error {
+		key, err := encryption.NewKey(appKey, purpose)
+		if err != nil {
+			return err
+		}
+
+		var users []user
+		if err := tx.Table("users").Select("id", "mfa_secret").Where("mfa_secret <> ''").Find(&users).Error; err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			secret, changed, err := convert(key, u.MfaSecret)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Table("users").Where("id = ?", u.ID).Update("mfa_secret", secret).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return Migration{
+		Version: 4,
+		Name:    "encrypt_mfa_secrets",
+		Up: func(tx *gorm.DB) error {
+			return rewrite(tx, func(key *encryption.Key, secret string) (string, bool, error) {
+				if encryption.IsEncrypted(secret) {
+					return secret, false, nil
+				}
+				encrypted, err := key.Encrypt(secret)
+				return encrypted, true, err
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			return rewrite(tx, func(key *encryption.Key, secret string) (string, bool, error) {
+				if !encryption.IsEncrypted(secret) {
+					return secret, false, nil
+				}
+				plaintext, err := key.Decrypt(secret)
+				return plaintext, true, err
+			})
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// actionTokenUserId gives action tokens the internal id of their user, so the
+// tokens of a user can be found whatever their subject. Subjects start with
+// the internal id, e.g. "<id>:<email>" for email verification; tokens whose
+// subject does not are left without a user.
+var actionTokenUserId = Migration{
+	Version: 5,
+	Name:    "action_token_user_id",
+	Up: func(tx *gorm.DB) error {
+		type actionToken struct {
+			gorm.Model
+			Subject string
+			UserId  uint `gorm:"index"`
+		}
+
+		migrator := tx.Migrator()
+
+		if !migrator.HasColumn(&actionToken{}, "UserId") {
+			if err := migrator.AddColumn(&actionToken{}, "UserId"); err != nil {
+				return err
+			}
+		}
+
+		var tokens []actionToken
+		err := tx.Unscoped().Where("user_id IS NULL OR user_id = 0").FindInBatches(&tokens, 500, func(batch *gorm.DB, _ int) error {
+			for _, token := range tokens {
+				prefix, _, _ := strings.Cut(token.Subject, ":")
+				userID, err := strconv.ParseUint(prefix, 10, strconv.IntSize)
+				if err != nil {
+					continue
+				}
+
+				if err := tx.Model(&actionToken{}).Unscoped().Where("id = ?", token.ID).Update("user_id", userID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if !migrator.HasIndex(&actionToken{}, "UserId") {
+			return migrator.CreateIndex(&actionToken{}, "UserId")
+		}
+
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		type actionToken struct {
+			gorm.Model
+			UserId uint `gorm:"index"`
+		}
+
+		migrator := tx.Migrator()
+		if migrator.HasIndex(&actionToken{}, "UserId") {
+			if err := migrator.DropIndex(&actionToken{}, "UserId"); err != nil {
+				return err
+			}
+		}
+
+		return migrator.DropColumn(&actionToken{}, "UserId")
+	},
+}
@@ -0,0 +1,248 @@
+// Package migrations versions the database schema. Every change to the schema
+// or to stored data is a Migration with an up and a down step, applied in
+// version order and recorded in the schema_migrations table.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaBehind is returned by Check when migrations are pending.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// Migration is one versioned change of the database. Up and Down run in a
+// transaction together with the bookkeeping in schema_migrations. MySQL commits
+// schema changes implicitly, so Up should succeed when run again after a
+// partial failure.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// all returns every migration of the service, in version order. Data
+// migrations of encrypted columns derive their keys from appKey.
+func all(appKey string) []Migration {
+	return []Migration{
+		initialSchema,
+		userPublicId,
+		revokedSessions,
+		encryptMfaSecrets(appKey),
+		actionTokenUserId,
+	}
+}
+
+// SchemaMigration is a row of schema_migrations, one per applied migration.
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status is the state of one migration.
+type Status struct {
+	Version int
+	Name    string
+	// AppliedAt is nil for pending migrations.
+	AppliedAt *time.Time
+	// Unknown is set for applied versions that are not part of this build,
+	// e.g. after rolling back to an older release.
+	Unknown bool
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for every migration of the service. appKey is the
+// APP_KEY the service runs with.
+func New(db *gorm.DB, appKey string) *Migrator {
+	return newMigrator(db, all(appKey))
+}
+
+func newMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			panic(fmt.Sprintf("migrations: duplicate version %d", sorted[i].Version))
+		}
+	}
+
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Up applies every pending migration in version order and returns the ones
+// applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	var done []Migration
+	for _, version := range versions {
+		if len(done) == steps {
+			break
+		}
+
+		migration, ok := m.find(version)
+		if !ok {
+			return done, fmt.Errorf("migration %d is applied but unknown to this build", version)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status returns every known and every applied migration in version order.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range applied {
+		row := row
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// Check returns ErrSchemaBehind when migrations of this build are not applied.
+// It only reads, the schema is changed by Up and Down.
+func (m *Migrator) Check() error {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return fmt.Errorf("%w: database is not migrated, run \"migrate up\"", ErrSchemaBehind)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("%w: %d pending migrations, run \"migrate up\"", ErrSchemaBehind, pending)
+	}
+
+	return nil
+}
+
+// applied returns the rows of schema_migrations by version. Nothing is applied
+// while the table does not exist, Up creates it.
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[int]SchemaMigration{}, nil
+	}
+
+	var rows []SchemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
@@ -0,0 +1,482 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-auth/server/database/databasetest"
+	"go-auth/server/lib/encryption"
+	"go-auth/server/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTo applies the migrations up to and including version.
+func migrateTo(t *testing.T, db *gorm.DB, version int) {
+	t.Helper()
+
+	var migrations []Migration
+	for _, migration := range all(databasetest.AppKey) {
+		if migration.Version <= version {
+			migrations = append(migrations, migration)
+		}
+	}
+	if _, err := newMigrator(db, migrations).Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testMigration returns a migration creating and dropping the table name. Up
+// fails when fail is set, after creating the table.
+func testMigration(version int, name string, fail bool) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE " + name + " (id INTEGER)").Error; err != nil {
+				return err
+			}
+			if fail {
+				return errors.New("failed")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE " + name).Error
+		},
+	}
+}
+
+// versions returns the versions of migrations.
+func versions(migrations []Migration) []int {
+	var versions []int
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+func equalVersions(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMigrator(t *testing.T) {
+	db := databasetest.Open(t)
+	first, second, third := testMigration(1, "first", false), testMigration(2, "second", false), testMigration(3, "third", false)
+	// Registered out of order, applied in version order.
+	migrator := newMigrator(db, []Migration{third, first, second})
+
+	steps := []struct {
+		name       string
+		run        func() ([]Migration, error)
+		want       []int
+		wantTables map[string]bool
+		wantBehind bool
+	}{
+		{"up", migrator.Up, []int{1, 2, 3}, map[string]bool{"first": true, "second": true, "third": true}, false},
+		{"up again", migrator.Up, nil, map[string]bool{"first": true, "second": true, "third": true}, false},
+		{"down", func() ([]Migration, error) { return migrator.Down(1) }, []int{3}, map[string]bool{"first": true, "second": true, "third": false}, true},
+		{"down two", func() ([]Migration, error) { return migrator.Down(2) }, []int{2, 1}, map[string]bool{"first": false, "second": false}, true},
+		{"down past the first", func() ([]Migration, error) { return migrator.Down(1) }, nil, map[string]bool{"first": false}, true},
+		{"up after down", migrator.Up, []int{1, 2, 3}, map[string]bool{"first": true, "third": true}, false},
+	}
+
+	for _, step := range steps {
+		done, err := step.run()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := versions(done); !equalVersions(got, step.want) {
+			t.Errorf("%s: ran %v, want %v", step.name, got, step.want)
+		}
+		for table, want := range step.wantTables {
+			if got := db.Migrator().HasTable(table); got != want {
+				t.Errorf("%s: table %s exists = %v, want %v", step.name, table, got, want)
+			}
+		}
+		if err := migrator.Check(); errors.Is(err, ErrSchemaBehind) != step.wantBehind {
+			t.Errorf("%s: Check = %v, want behind %v", step.name, err, step.wantBehind)
+		}
+	}
+}
+
+// A failing migration is rolled back together with its bookkeeping and stops
+// the ones after it.
+func TestMigratorFailure(t *testing.T) {
+	db := databasetest.Open(t)
+	migrator := newMigrator(db, []Migration{
+		testMigration(1, "first", false),
+		testMigration(2, "broken", true),
+		testMigration(3, "third", false),
+	})
+
+	done, err := migrator.Up()
+	if err == nil {
+		t.Fatal("Up succeeded, want error")
+	}
+	if got := versions(done); !equalVersions(got, []int{1}) {
+		t.Errorf("applied %v, want [1]", got)
+	}
+
+	tests := []struct {
+		table string
+		want  bool
+	}{
+		{"first", true},
+		{"broken", false},
+		{"third", false},
+	}
+	for _, tc := range tests {
+		if got := db.Migrator().HasTable(tc.table); got != tc.want {
+			t.Errorf("table %s exists = %v, want %v", tc.table, got, tc.want)
+		}
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if applied := status.AppliedAt != nil; applied != (status.Version == 1) {
+			t.Errorf("migration %d applied = %v", status.Version, applied)
+		}
+	}
+}
+
+// Versions applied by a newer build are reported and block reverting past
+// them.
+func TestMigratorUnknownVersion(t *testing.T) {
+	db := databasetest.Open(t)
+	if _, err := newMigrator(db, []Migration{testMigration(1, "first", false), testMigration(2, "newer", false)}).Up(); err != nil {
+		t.Fatal(err)
+	}
+	migrator := newMigrator(db, []Migration{testMigration(1, "first", false)})
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Status{{Version: 1, Name: "first"}, {Version: 2, Name: "newer", Unknown: true}}
+	if len(statuses) != len(want) {
+		t.Fatalf("%d statuses, want %d", len(statuses), len(want))
+	}
+	for i, status := range statuses {
+		if status.Version != want[i].Version || status.Name != want[i].Name || status.Unknown != want[i].Unknown || status.AppliedAt == nil {
+			t.Errorf("status %d = %+v, want %+v applied", i, status, want[i])
+		}
+	}
+
+	if err := migrator.Check(); err != nil {
+		t.Errorf("Check = %v, want nil", err)
+	}
+	if _, err := migrator.Down(1); err == nil {
+		t.Error("Down of an unknown version succeeded")
+	}
+}
+
+// Check only reads, a database that was never migrated is reported as behind
+// and left as it is.
+func TestMigratorCheckUnmigrated(t *testing.T) {
+	db := databasetest.Open(t)
+	migrator := newMigrator(db, []Migration{testMigration(1, "first", false)})
+
+	if err := migrator.Check(); !errors.Is(err, ErrSchemaBehind) {
+		t.Errorf("Check = %v, want %v", err, ErrSchemaBehind)
+	}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		t.Error("Check created the schema_migrations table")
+	}
+}
+
+func TestNewMigratorDuplicateVersion(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("newMigrator with a duplicate version did not panic")
+		}
+	}()
+
+	newMigrator(nil, []Migration{testMigration(1, "first", false), testMigration(1, "second", false)})
+}
+
+// Every migration of the service reverts cleanly and applies again.
+func TestAllMigrationsRoundTrip(t *testing.T) {
+	db := databasetest.Open(t)
+	migrator := New(db, databasetest.AppKey)
+
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(len(all(databasetest.AppKey))); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		// sqlite_sequence is SQLite's own table of AUTOINCREMENT counters.
+		if table != "schema_migrations" && table != "sqlite_sequence" {
+			t.Errorf("table %s left after reverting every migration", table)
+		}
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Databases created by AutoMigrate before versioned migrations existed are
+// adopted by the initial schema without changes.
+func TestInitialSchemaOnExistingDatabase(t *testing.T) {
+	db := databasetest.Open(t)
+	if err := initialSchema.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO users (id, name, password) VALUES (1, 'alice', 'x')").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newMigrator(db, []Migration{initialSchema}).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err := db.Table("users").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("%d users, %v, want 1", count, err)
+	}
+}
+
+func TestEncryptMfaSecrets(t *testing.T) {
+	db := databasetest.Open(t)
+	migrateTo(t, db, 3)
+
+	secrets := map[string]string{
+		"enrolled":     "JBSWY3DPEHPK3PXP",
+		"not enrolled": "",
+	}
+	for name, secret := range secrets {
+		if err := db.Create(&models.User{Name: name, Password: "x", MfaSecret: secret}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key, err := encryption.NewKey(databasetest.AppKey, models.MfaSecretPurpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := func(name string) string {
+		user := &models.User{}
+		if err := db.Where("name = ?", name).First(user).Error; err != nil {
+			t.Fatal(err)
+		}
+		return user.MfaSecret
+	}
+
+	migrator := New(db, databasetest.AppKey)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	for name, secret := range secrets {
+		got := stored(name)
+		if secret == "" {
+			if got != "" {
+				t.Errorf("%s: secret = %q, want empty", name, got)
+			}
+			continue
+		}
+		if plaintext, err := key.Decrypt(got); err != nil || plaintext != secret {
+			t.Errorf("%s: Decrypt(%q) = %q, %v, want %q", name, got, plaintext, err, secret)
+		}
+	}
+
+	// Back to the version before the migration.
+	if _, err := migrator.Down(len(all(databasetest.AppKey)) - 3); err != nil {
+		t.Fatal(err)
+	}
+	for name, secret := range secrets {
+		if got := stored(name); got != secret {
+			t.Errorf("%s: secret after down = %q, want %q", name, got, secret)
+		}
+	}
+}
+
+func TestUserPublicId(t *testing.T) {
+	db := databasetest.Open(t)
+	migrateTo(t, db, 1)
+
+	// Users as stored before the migration, with tokens issued to their
+	// internal id.
+	statements := []string{
+		"INSERT INTO users (id, name, password, created_at) VALUES (1, 'alice', 'x', '2020-01-02 03:04:05')",
+		"INSERT INTO users (id, name, password, deleted_at) VALUES (2, 'bob', 'x', '2021-01-01 00:00:00')",
+		"INSERT INTO users (id, name, password, created_at) VALUES (3, 'bob', 'x', '2023-01-01 00:00:00')",
+		"INSERT INTO users (id, name, password, deleted_at) VALUES (4, 'carol', 'x', '2021-01-01 00:00:00')",
+		"INSERT INTO users (id, name, password, deleted_at) VALUES (5, 'carol', 'x', '2022-01-01 00:00:00')",
+		"INSERT INTO refresh_tokens (jti, family_id, user_id) VALUES ('refresh', 'family', '1')",
+		"INSERT INTO revoked_tokens (jti, user_id) VALUES ('revoked', '3')",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrator := newMigrator(db, []Migration{initialSchema, userPublicId})
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		ID       uint
+		Name     string
+		PublicId string
+	}
+	var rows []row
+	if err := db.Table("users").Order("id").Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	publicIDs := map[uint]string{}
+	for _, r := range rows {
+		publicIDs[r.ID] = r.PublicId
+	}
+
+	tests := []struct {
+		id       uint
+		wantName string
+	}{
+		{1, "alice"},
+		{2, "bob~deleted~2"},
+		{3, "bob"},
+		{4, "carol~deleted~4"},
+		{5, "carol"},
+	}
+	for i, tc := range tests {
+		if rows[i].ID != tc.id || rows[i].Name != tc.wantName {
+			t.Errorf("user %d is named %q, want %q", rows[i].ID, rows[i].Name, tc.wantName)
+		}
+		if len(rows[i].PublicId) != 26 {
+			t.Errorf("user %d has public id %q", rows[i].ID, rows[i].PublicId)
+		}
+	}
+	// The public id keeps the creation time of the user.
+	if publicIDs[1] >= publicIDs[3] {
+		t.Errorf("public id of the older user %s sorts after %s", publicIDs[1], publicIDs[3])
+	}
+
+	subjects := []struct {
+		table string
+		jti   string
+		want  string
+	}{
+		{"refresh_tokens", "refresh", publicIDs[1]},
+		{"revoked_tokens", "revoked", publicIDs[3]},
+	}
+	subject := func(table, jti string) string {
+		var userID string
+		if err := db.Table(table).Where("jti = ?", jti).Pluck("user_id", &userID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return userID
+	}
+	for _, tc := range subjects {
+		if got := subject(tc.table, tc.jti); got != tc.want {
+			t.Errorf("%s user_id = %q, want %q", tc.table, got, tc.want)
+		}
+	}
+
+	if err := db.Exec("INSERT INTO users (name, password, public_id) VALUES ('alice', 'x', 'other')").Error; err == nil {
+		t.Error("names are not unique after the migration")
+	}
+
+	if _, err := migrator.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range subjects {
+		want := map[string]string{"refresh": "1", "revoked": "3"}[tc.jti]
+		if got := subject(tc.table, tc.jti); got != want {
+			t.Errorf("%s user_id after down = %q, want %q", tc.table, got, want)
+		}
+	}
+	if db.Migrator().HasColumn("users", "public_id") {
+		t.Error("public_id column left after down")
+	}
+}
+
+// Active users sharing a name cannot be told apart by the migration.
+func TestUserPublicIdActiveDuplicates(t *testing.T) {
+	db := databasetest.Open(t)
+	migrateTo(t, db, 1)
+	for _, statement := range []string{
+		"INSERT INTO users (id, name, password) VALUES (1, 'alice', 'x')",
+		"INSERT INTO users (id, name, password) VALUES (2, 'alice', 'x')",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := newMigrator(db, []Migration{initialSchema, userPublicId}).Up(); err == nil {
+		t.Fatal("Up succeeded, want error")
+	}
+
+	var names []string
+	if err := db.Table("users").Order("id").Pluck("name", &names).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "alice" || names[1] != "alice" {
+		t.Errorf("names = %v, want the users left alone", names)
+	}
+}
+
+func TestActionTokenUserId(t *testing.T) {
+	db := databasetest.Open(t)
+	migrateTo(t, db, 4)
+
+	subjects := map[string]uint{
+		"7":                   7,
+		"7:alice@example.com": 7,
+		"alice@example.com":   0,
+	}
+	for subject := range subjects {
+		err := db.Table("action_tokens").Create(map[string]interface{}{
+			"purpose":    "test",
+			"subject":    subject,
+			"token_hash": subject,
+			"expires_at": time.Now(),
+		}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrator := New(db, databasetest.AppKey)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	for subject, want := range subjects {
+		var token models.ActionToken
+		if err := db.Where("subject = ?", subject).First(&token).Error; err != nil {
+			t.Fatal(err)
+		}
+		if token.UserId != want {
+			t.Errorf("%s: user id %d, want %d", subject, token.UserId, want)
+		}
+	}
+
+	if _, err := migrator.Down(len(all(databasetest.AppKey)) - 4); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn("action_tokens", "user_id") {
+		t.Error("user_id column left after down")
+	}
+}
@@ -0,0 +1,38 @@
+// Package migrationstest provides migrated databases and the fixtures stored
+// in them for tests.
+package migrationstest
+
+import (
+	"testing"
+
+	"go-auth/server/database/databasetest"
+	"go-auth/server/migrations"
+	"go-auth/server/models"
+
+	"gorm.io/gorm"
+)
+
+// Open returns a SQLite database with every migration applied, see
+// databasetest.Open.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db := databasetest.Open(t)
+	if _, err := migrations.New(db, databasetest.AppKey).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// CreateUser stores an active user with name.
+func CreateUser(t testing.TB, db *gorm.DB, name string) *models.User {
+	t.Helper()
+
+	user := &models.User{Name: name, Password: "hash", Status: models.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user %s: %v", name, err)
+	}
+
+	return user
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActionToken is a single-use token authorizing one action, such as a
+// password reset, for its subject. Only the SHA-256 hash of the token sent to
+// the user is stored. UserId is the internal id of the user the token was
+// issued to, the subject may carry more, e.g. the email address to verify.
+type ActionToken struct {
+	gorm.Model
+	Purpose    string `gorm:"size:64;index:idx_action_tokens_subject"`
+	Subject    string `gorm:"size:320;index:idx_action_tokens_subject"`
+	UserId     uint   `gorm:"index"`
+	TokenHash  string `gorm:"size:64;uniqueIndex"`
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time
+}
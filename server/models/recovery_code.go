@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use MFA recovery code. Only the SHA-256 hash of the
+// code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserId   uint   `gorm:"index"`
+	CodeHash string `gorm:"size:64;uniqueIndex"`
+	UsedAt   *time.Time
+}
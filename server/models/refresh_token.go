@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a single issued refresh token. Every rotation creates a new
+// row in the same family, so presenting an already rotated token reveals a
+// replay and lets the whole family be revoked at once.
+type RefreshToken struct {
+	gorm.Model
+	Jti       string `gorm:"size:64;uniqueIndex"`
+	FamilyId  string `gorm:"size:64;index"`
+	UserId    string `gorm:"size:64;index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
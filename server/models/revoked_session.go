@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevokedSession ends every session of a user: access tokens and MFA
+// challenges issued before RevokedBefore are rejected. Rows can be purged once
+// ExpiresAt has passed, the tokens they reject have expired by then.
+type RevokedSession struct {
+	gorm.Model
+	UserId        string `gorm:"size:64;uniqueIndex"`
+	RevokedBefore time.Time
+	ExpiresAt     time.Time `gorm:"index"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RevokedToken records an access token that must be rejected before it
+// expires. Rows can be purged once ExpiresAt has passed.
+type RevokedToken struct {
+	gorm.Model
+	Jti       string    `gorm:"size:64;uniqueIndex"`
+	UserId    string    `gorm:"size:64;index"`
+	ExpiresAt time.Time `gorm:"index"`
+}
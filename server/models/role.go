@@ -0,0 +1,27 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	AdminRole = "admin"
+	UserRole  = "user"
+
+	// PermissionManageRoles allows managing roles, permissions and their
+	// assignment to users.
+	PermissionManageRoles = "rbac:manage"
+	// PermissionManageUsers allows administering user accounts.
+	PermissionManageUsers = "users:manage"
+)
+
+type Role struct {
+	gorm.Model
+	Name        string `gorm:"size:64;uniqueIndex"`
+	Description string
+	Permissions []Permission `gorm:"many2many:role_permissions;"`
+}
+
+type Permission struct {
+	gorm.Model
+	Name        string `gorm:"size:128;uniqueIndex"`
+	Description string
+}
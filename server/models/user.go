@@ -1,16 +1,69 @@
 package models
 
 import (
+	"go-auth/server/lib/ulid"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	// Registered with an email address that has not been verified yet.
+	UserStatusPendingVerification = "pending_verification"
+)
+
 type User struct {
 	gorm.Model
-	Id        int `gorm:"uniqueIndex"`
-	Name      string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// PublicId identifies the user outside the service, e.g. as the subject of
+	// tokens. Unlike ID it does not reveal how many accounts exist.
+	PublicId string `gorm:"size:26;uniqueIndex"`
+	// Names of soft deleted users stay taken.
+	Name     string `gorm:"size:191;uniqueIndex"`
+	Password string
+	Roles    []Role `gorm:"many2many:user_roles;"`
+
+	// Disabled accounts cannot log in. Deleted accounts are soft deleted
+	// through gorm.Model instead.
+	Status string `gorm:"size:32;default:active;index"`
+
+	// Profile. Email is NULL when unset so the unique index only applies to
+	// users that have one.
+	Email           *string `gorm:"size:254;uniqueIndex"`
+	EmailVerifiedAt *time.Time
+	DisplayName     string `gorm:"size:100"`
+	AvatarUrl       string `gorm:"size:2048"`
+	Locale          string `gorm:"size:35"`
+	Timezone        string `gorm:"size:64"`
+	Phone           string `gorm:"size:16"`
+
+	// TOTP secret, encrypted with the key of MfaSecretPurpose. It is set on
+	// enrollment and only used for login once MfaEnabled is true.
+	MfaSecret   string
+	MfaEnabled  bool
+	MfaLastStep int64
+
+	// Consecutive failed logins, reset on success.
+	FailedLoginCount  int
+	LastFailedLoginAt *time.Time
+	LockedUntil       *time.Time
+}
+
+// MfaSecretPurpose is the purpose of the encryption key of MfaSecret.
+const MfaSecretPurpose = "mfa secret"
+
+// BeforeCreate assigns the public id of new users.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.PublicId != "" {
+		return nil
+	}
+
+	id, err := ulid.New()
+	if err != nil {
+		return err
+	}
+	u.PublicId = id
+
+	return nil
 }
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+
+	"gorm.io/gorm"
+)
+
+// createUser stores a user with name and returns it.
+func createUser(t *testing.T, users UserRepository, name string) *models.User {
+	t.Helper()
+
+	user := &models.User{Name: name, Password: "hash", Status: models.UserStatusActive}
+	if err := users.Create(user); err != nil {
+		t.Fatalf("Create(%s): %v", name, err)
+	}
+
+	return user
+}
+
+func TestTransactor(t *testing.T) {
+	db := migrationstest.Open(t)
+	transactor := NewGormTransactor(db)
+	users := NewGormUserRepository(db)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStored bool
+	}{
+		{"committed", nil, true},
+		{"rolled back", errors.New("failed"), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := transactor.Transaction(func(tx *gorm.DB) error {
+				createUser(t, users.WithTx(tx), tc.name)
+				return tc.err
+			})
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Transaction = %v, want %v", err, tc.err)
+			}
+
+			_, err = users.FindByName(tc.name)
+			if stored := err == nil; stored != tc.wantStored {
+				t.Errorf("stored = %v, want %v (%v)", stored, tc.wantStored, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"go-auth/server/models"
+
+	"gorm.io/gorm"
+)
+
+// RoleRepository stores roles and the permissions granted to them. Lookups of
+// a single role return gorm.ErrRecordNotFound when there is no such role.
+type RoleRepository interface {
+	// WithTx returns a repository running its queries in tx.
+	WithTx(tx *gorm.DB) RoleRepository
+
+	FindByName(name string) (*models.Role, error)
+	// FindWithPermissions loads the role together with its permissions.
+	FindWithPermissions(name string) (*models.Role, error)
+	// FindByNames returns the roles with the given names, names without a
+	// role are skipped.
+	FindByNames(names []string) ([]models.Role, error)
+	// List returns every role with its permissions, ordered by name.
+	List() ([]models.Role, error)
+
+	// Create stores a new role together with its permission grants.
+	Create(role *models.Role) error
+	// FirstOrCreate loads the role with the name of role into role, creating
+	// it from role when there is none.
+	FirstOrCreate(role *models.Role) error
+	// Delete removes the role, its permission grants and its assignments to
+	// users.
+	Delete(role *models.Role) error
+
+	// FindPermissions returns the permissions with the given names, names
+	// without a permission are skipped.
+	FindPermissions(names []string) ([]models.Permission, error)
+	// ListPermissions returns every permission ordered by name.
+	ListPermissions() ([]models.Permission, error)
+	CreatePermission(permission *models.Permission) error
+	// FirstOrCreatePermission loads the permission with the name of
+	// permission into permission, creating it when there is none.
+	FirstOrCreatePermission(permission *models.Permission) error
+	// Grant and Revoke add and remove permission grants of the role.
+	Grant(role *models.Role, permissions []models.Permission) error
+	Revoke(role *models.Role, permissions []models.Permission) error
+}
+
+type gormRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRoleRepository(db *gorm.DB) RoleRepository {
+	return &gormRoleRepository{db: db}
+}
+
+func (r *gormRoleRepository) WithTx(tx *gorm.DB) RoleRepository {
+	return &gormRoleRepository{db: tx}
+}
+
+func (r *gormRoleRepository) FindByName(name string) (*models.Role, error) {
+	return r.first(r.db.Where("name = ?", name))
+}
+
+func (r *gormRoleRepository) FindWithPermissions(name string) (*models.Role, error) {
+	return r.first(r.db.Preload("Permissions").Where("name = ?", name))
+}
+
+func (r *gormRoleRepository) FindByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Where("name IN ?", names).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *gormRoleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *gormRoleRepository) Create(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *gormRoleRepository) FirstOrCreate(role *models.Role) error {
+	return r.db.Where(models.Role{Name: role.Name}).Attrs(*role).FirstOrCreate(role).Error
+}
+
+func (r *gormRoleRepository) Delete(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", role.ID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(role).Error
+	})
+}
+
+func (r *gormRoleRepository) FindPermissions(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if err := r.db.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *gormRoleRepository) ListPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	if err := r.db.Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *gormRoleRepository) CreatePermission(permission *models.Permission) error {
+	return r.db.Create(permission).Error
+}
+
+func (r *gormRoleRepository) FirstOrCreatePermission(permission *models.Permission) error {
+	return r.db.Where(models.Permission{Name: permission.Name}).Attrs(*permission).FirstOrCreate(permission).Error
+}
+
+func (r *gormRoleRepository) Grant(role *models.Role, permissions []models.Permission) error {
+	return r.db.Model(role).Association("Permissions").Append(permissions)
+}
+
+func (r *gormRoleRepository) Revoke(role *models.Role, permissions []models.Permission) error {
+	return r.db.Model(role).Association("Permissions").Delete(permissions)
+}
+
+func (r *gormRoleRepository) first(query *gorm.DB) (*models.Role, error) {
+	role := &models.Role{}
+	if err := query.First(role).Error; err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
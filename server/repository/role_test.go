@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"sort"
+	"testing"
+
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+)
+
+func TestRoleRepositoryFirstOrCreate(t *testing.T) {
+	roles := NewGormRoleRepository(migrationstest.Open(t))
+
+	first := &models.Role{Name: "support", Description: "Support"}
+	if err := roles.FirstOrCreate(first); err != nil {
+		t.Fatal(err)
+	}
+	again := &models.Role{Name: "support", Description: "Changed"}
+	if err := roles.FirstOrCreate(again); err != nil {
+		t.Fatal(err)
+	}
+
+	if again.ID != first.ID {
+		t.Errorf("second FirstOrCreate returned role %d, want %d", again.ID, first.ID)
+	}
+	list, err := roles.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("%d roles stored, want 1", len(list))
+	}
+}
+
+func TestRoleRepositoryFindByNames(t *testing.T) {
+	roles := NewGormRoleRepository(migrationstest.Open(t))
+	for _, name := range []string{"admin", "support"} {
+		if err := roles.Create(&models.Role{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{"none", nil, nil},
+		{"one", []string{"admin"}, []string{"admin"}},
+		{"all", []string{"support", "admin"}, []string{"admin", "support"}},
+		{"unknown name skipped", []string{"admin", "unknown"}, []string{"admin"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := roles.FindByNames(tc.names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, role := range found {
+				got = append(got, role.Name)
+			}
+			sort.Strings(got)
+			if !equal(got, tc.want) {
+				t.Errorf("FindByNames(%v) = %v, want %v", tc.names, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRoleRepositoryGrantRevoke(t *testing.T) {
+	roles := NewGormRoleRepository(migrationstest.Open(t))
+	role := &models.Role{Name: "support"}
+	if err := roles.Create(role); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"read", "write"} {
+		if err := roles.CreatePermission(&models.Permission{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	permissions := func(names ...string) []models.Permission {
+		found, err := roles.FindPermissions(names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	tests := []struct {
+		name   string
+		change func() error
+		want   []string
+	}{
+		{"grant", func() error { return roles.Grant(role, permissions("read")) }, []string{"read"}},
+		{"grant again", func() error { return roles.Grant(role, permissions("read", "write")) }, []string{"read", "write"}},
+		{"revoke", func() error { return roles.Revoke(role, permissions("read")) }, []string{"write"}},
+		{"revoke missing", func() error { return roles.Revoke(role, permissions("read")) }, []string{"write"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.change(); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := roles.FindWithPermissions(role.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, permission := range stored.Permissions {
+				got = append(got, permission.Name)
+			}
+			sort.Strings(got)
+			if !equal(got, tc.want) {
+				t.Errorf("permissions = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Deleting a role removes its assignments, so the name can be used again.
+func TestRoleRepositoryDelete(t *testing.T) {
+	db := migrationstest.Open(t)
+	roles := NewGormRoleRepository(db)
+	users := NewGormUserRepository(db)
+
+	role := &models.Role{Name: "support"}
+	if err := roles.Create(role); err != nil {
+		t.Fatal(err)
+	}
+	if err := roles.CreatePermission(&models.Permission{Name: "read"}); err != nil {
+		t.Fatal(err)
+	}
+	read, err := roles.FindPermissions([]string{"read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := roles.Grant(role, read); err != nil {
+		t.Fatal(err)
+	}
+	user := createUser(t, users, "alice")
+	if err := users.AddRole(user, role); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := roles.Delete(role); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"user_roles", "role_permissions"} {
+		var count int64
+		if err := db.Table(table).Where("role_id = ?", role.ID).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%d rows left in %s", count, table)
+		}
+	}
+	if err := roles.Create(&models.Role{Name: "support"}); err != nil {
+		t.Errorf("recreating the role: %v", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,23 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Transactor runs changes spanning several repositories atomically. The
+// repositories join the transaction with their WithTx method.
+type Transactor interface {
+	// Transaction runs fn in a transaction, committed when fn returns nil and
+	// rolled back otherwise.
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func NewGormTransactor(db *gorm.DB) Transactor {
+	return &gormTransactor{db: db}
+}
+
+func (t *gormTransactor) Transaction(fn func(tx *gorm.DB) error) error {
+	return t.db.Transaction(fn)
+}
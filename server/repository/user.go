@@ -0,0 +1,295 @@
+// Package repository stores the accounts of the service.
+package repository
+
+import (
+	"errors"
+	"go-auth/server/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserRepository stores user accounts. Lookups return gorm.ErrRecordNotFound
+// when there is no matching user, soft deleted users are only found by the
+// methods saying so.
+type UserRepository interface {
+	// WithTx returns a repository running its queries in tx.
+	WithTx(tx *gorm.DB) UserRepository
+
+	FindByID(id uint) (*models.User, error)
+	// FindAnyByID also finds soft deleted users.
+	FindAnyByID(id uint) (*models.User, error)
+	FindByPublicID(publicID string) (*models.User, error)
+	FindByName(name string) (*models.User, error)
+	// FindWithRoles loads the user together with their roles.
+	FindWithRoles(id uint) (*models.User, error)
+	// FindTaken returns a user, soft deleted or not, with the given name or
+	// email, nil when there is none. An empty email is ignored.
+	FindTaken(name, email string) (*models.User, error)
+	// NameTaken and EmailTaken report whether a user other than exceptID uses
+	// the name or email. Soft deleted users keep both.
+	NameTaken(name string, exceptID uint) (bool, error)
+	EmailTaken(email string, exceptID uint) (bool, error)
+	// List returns users in id order, see UserFilter.
+	List(filter UserFilter) ([]models.User, error)
+
+	// Create stores a new user and assigns its ID and PublicId.
+	Create(user *models.User) error
+	// Update sets the given columns of the user.
+	Update(id uint, updates map[string]interface{}) error
+	// SwapPassword replaces the password hash of the user only while it is
+	// still oldHash, and reports whether it did.
+	SwapPassword(id uint, oldHash, newHash string) (bool, error)
+	// AdvanceMfaStep records step as the last TOTP step used by the user. It
+	// reports false when the same or a later step was used before.
+	AdvanceMfaStep(id uint, step int64) (bool, error)
+	// RecordLoginFailure counts a failed login at the given time and returns
+	// the number of consecutive failures. Failures before window are
+	// forgotten.
+	RecordLoginFailure(id uint, at time.Time, window time.Duration) (int, error)
+
+	// ReplaceRecoveryCodes replaces the MFA recovery codes of the user with
+	// the given code hashes, removing all when hashes is empty.
+	ReplaceRecoveryCodes(id uint, hashes []string) error
+	// UseRecoveryCode marks the unused recovery code with the hash as used at
+	// the given time and reports whether the user had one.
+	UseRecoveryCode(id uint, hash string, at time.Time) (bool, error)
+
+	AddRole(user *models.User, role *models.Role) error
+	RemoveRole(user *models.User, role *models.Role) error
+	// ReplaceRoles sets the roles of the user to roles, removing all when
+	// roles is empty.
+	ReplaceRoles(user *models.User, roles []models.Role) error
+
+	SoftDelete(id uint) error
+	// HardDelete removes the user, soft deleted or not, with their role
+	// assignments, recovery codes and refresh tokens.
+	HardDelete(user *models.User) error
+}
+
+// UserFilter selects the users returned by List.
+type UserFilter struct {
+	NamePrefix string
+	// Status selects users with the status, all statuses when empty.
+	Status string
+	// Deleted selects soft deleted users instead of the others.
+	Deleted       bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// AfterID selects users with a larger id, for paging.
+	AfterID uint
+	// At most Limit users are returned.
+	Limit int
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+func (r *gormUserRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &gormUserRepository{db: tx}
+}
+
+func (r *gormUserRepository) FindByID(id uint) (*models.User, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *gormUserRepository) FindAnyByID(id uint) (*models.User, error) {
+	return r.first(r.db.Unscoped().Where("id = ?", id))
+}
+
+func (r *gormUserRepository) FindByPublicID(publicID string) (*models.User, error) {
+	return r.first(r.db.Where("public_id = ?", publicID))
+}
+
+func (r *gormUserRepository) FindByName(name string) (*models.User, error) {
+	return r.first(r.db.Where("name = ?", name))
+}
+
+func (r *gormUserRepository) FindWithRoles(id uint) (*models.User, error) {
+	return r.first(r.db.Preload("Roles").Where("id = ?", id))
+}
+
+func (r *gormUserRepository) FindTaken(name, email string) (*models.User, error) {
+	query := r.db.Unscoped().Where("name = ?", name)
+	if email != "" {
+		query = query.Or("email = ?", email)
+	}
+
+	user, err := r.first(query)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+func (r *gormUserRepository) NameTaken(name string, exceptID uint) (bool, error) {
+	return r.exists(r.db.Unscoped().Where("name = ? AND id <> ?", name, exceptID))
+}
+
+func (r *gormUserRepository) EmailTaken(email string, exceptID uint) (bool, error) {
+	return r.exists(r.db.Unscoped().Where("email = ? AND id <> ?", email, exceptID))
+}
+
+func (r *gormUserRepository) List(filter UserFilter) ([]models.User, error) {
+	query := r.db.Preload("Roles")
+
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.NamePrefix != "" {
+		query = query.Where("name LIKE ? ESCAPE '!'", escapeLike(filter.NamePrefix)+"%")
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+
+	var users []models.User
+	if err := query.Order("id").Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *gormUserRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *gormUserRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *gormUserRepository) SwapPassword(id uint, oldHash, newHash string) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		Update("password", newHash)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormUserRepository) AdvanceMfaStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND mfa_last_step < ?", id, step).
+		Update("mfa_last_step", step)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormUserRepository) RecordLoginFailure(id uint, at time.Time, window time.Duration) (int, error) {
+	err := r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_count": gorm.Expr(
+			"CASE WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1 ELSE failed_login_count + 1 END",
+			at.Add(-window),
+		),
+		"last_failed_login_at": at,
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	updated := &models.User{}
+	if err := r.db.Select("failed_login_count").Where("id = ?", id).First(updated).Error; err != nil {
+		return 0, err
+	}
+
+	return updated.FailedLoginCount, nil
+}
+
+func (r *gormUserRepository) ReplaceRecoveryCodes(id uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+
+		codes := make([]models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, models.RecoveryCode{UserId: id, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *gormUserRepository) UseRecoveryCode(id uint, hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", id, hash).
+		Update("used_at", at)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormUserRepository) AddRole(user *models.User, role *models.Role) error {
+	return r.db.Model(user).Association("Roles").Append(role)
+}
+
+func (r *gormUserRepository) RemoveRole(user *models.User, role *models.Role) error {
+	return r.db.Model(user).Association("Roles").Delete(role)
+}
+
+func (r *gormUserRepository) ReplaceRoles(user *models.User, roles []models.Role) error {
+	if len(roles) == 0 {
+		return r.db.Model(user).Association("Roles").Clear()
+	}
+
+	return r.db.Model(user).Association("Roles").Replace(roles)
+}
+
+func (r *gormUserRepository) SoftDelete(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&models.User{}).Error
+}
+
+func (r *gormUserRepository) HardDelete(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Association("Roles").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.PublicId).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", user.ID).Delete(&models.User{}).Error
+	})
+}
+
+func (r *gormUserRepository) first(query *gorm.DB) (*models.User, error) {
+	user := &models.User{}
+	if err := query.First(user).Error; err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *gormUserRepository) exists(query *gorm.DB) (bool, error) {
+	var count int64
+	if err := query.Model(&models.User{}).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// escapeLike escapes the LIKE wildcards of s for use with ESCAPE '!'.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-auth/server/migrations/migrationstest"
+	"go-auth/server/models"
+
+	"gorm.io/gorm"
+)
+
+func TestUserRepositoryFind(t *testing.T) {
+	users := NewGormUserRepository(migrationstest.Open(t))
+	alice := createUser(t, users, "alice")
+	email := "bob@example.com"
+	bob := &models.User{Name: "bob", Password: "hash", Email: &email}
+	if err := users.Create(bob); err != nil {
+		t.Fatal(err)
+	}
+	deleted := createUser(t, users, "deleted")
+	if err := users.SoftDelete(deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		find   func() (*models.User, error)
+		wantID uint
+	}{
+		{"by id", func() (*models.User, error) { return users.FindByID(alice.ID) }, alice.ID},
+		{"by public id", func() (*models.User, error) { return users.FindByPublicID(alice.PublicId) }, alice.ID},
+		{"by name", func() (*models.User, error) { return users.FindByName("alice") }, alice.ID},
+		{"deleted by id", func() (*models.User, error) { return users.FindByID(deleted.ID) }, 0},
+		{"deleted by name", func() (*models.User, error) { return users.FindByName("deleted") }, 0},
+		{"any deleted by id", func() (*models.User, error) { return users.FindAnyByID(deleted.ID) }, deleted.ID},
+		{"taken name", func() (*models.User, error) { return users.FindTaken("alice", "") }, alice.ID},
+		{"taken email", func() (*models.User, error) { return users.FindTaken("other", email) }, bob.ID},
+		{"taken by deleted user", func() (*models.User, error) { return users.FindTaken("deleted", "") }, deleted.ID},
+		{"not taken", func() (*models.User, error) { return users.FindTaken("other", "other@example.com") }, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := tc.find()
+			if tc.wantID == 0 {
+				if user != nil || (err != nil && !errors.Is(err, gorm.ErrRecordNotFound)) {
+					t.Errorf("found %v, %v, want none", user, err)
+				}
+				return
+			}
+			if err != nil || user.ID != tc.wantID {
+				t.Errorf("found %v, %v, want user %d", user, err, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	users := NewGormUserRepository(migrationstest.Open(t))
+	user := createUser(t, users, "alice")
+	start := time.Now()
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"first failure", start, 1},
+		{"within window", start.Add(time.Minute), 2},
+		{"still within window", start.Add(10 * time.Minute), 3},
+		{"after window", start.Add(time.Hour), 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := users.RecordLoginFailure(user.ID, tc.at, 15*time.Minute)
+			if err != nil || got != tc.want {
+				t.Errorf("RecordLoginFailure = %d, %v, want %d", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAdvanceMfaStep(t *testing.T) {
+	users := NewGormUserRepository(migrationstest.Open(t))
+	user := createUser(t, users, "alice")
+
+	tests := []struct {
+		step int64
+		want bool
+	}{
+		{100, true},
+		{100, false},
+		{99, false},
+		{101, true},
+	}
+
+	for _, tc := range tests {
+		advanced, err := users.AdvanceMfaStep(user.ID, tc.step)
+		if err != nil || advanced != tc.want {
+			t.Errorf("AdvanceMfaStep(%d) = %v, %v, want %v", tc.step, advanced, err, tc.want)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	users := NewGormUserRepository(migrationstest.Open(t))
+	alice := createUser(t, users, "alice")
+	bob := createUser(t, users, "bob")
+
+	tests := []struct {
+		name   string
+		action func() error
+		user   uint
+		hash   string
+		want   bool
+	}{
+		{"unknown code", func() error { return users.ReplaceRecoveryCodes(alice.ID, []string{"a1", "a2"}) }, alice.ID, "zz", false},
+		{"code of another user", func() error { return nil }, bob.ID, "a1", false},
+		{"first use", func() error { return nil }, alice.ID, "a1", true},
+		{"second use", func() error { return nil }, alice.ID, "a1", false},
+		{"replaced codes", func() error { return users.ReplaceRecoveryCodes(alice.ID, []string{"a3"}) }, alice.ID, "a2", false},
+		{"new code", func() error { return nil }, alice.ID, "a3", true},
+		{"removed codes", func() error { return users.ReplaceRecoveryCodes(bob.ID, nil) }, bob.ID, "a3", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.action(); err != nil {
+				t.Fatal(err)
+			}
+			used, err := users.UseRecoveryCode(tc.user, tc.hash, time.Now())
+			if err != nil || used != tc.want {
+				t.Errorf("UseRecoveryCode(%d, %s) = %v, %v, want %v", tc.user, tc.hash, used, err, tc.want)
+			}
+		})
+	}
+}
+
+// Hard deleting a user removes the rows referencing it, so the name and
+// email can be used again.
+func TestHardDelete(t *testing.T) {
+	db := migrationstest.Open(t)
+	users := NewGormUserRepository(db)
+	roles := NewGormRoleRepository(db)
+
+	role := &models.Role{Name: "support"}
+	if err := roles.Create(role); err != nil {
+		t.Fatal(err)
+	}
+	user := createUser(t, users, "alice")
+	if err := users.AddRole(user, role); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.ReplaceRecoveryCodes(user.ID, []string{"a1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.RefreshToken{Jti: "jti", FamilyId: "family", UserId: user.PublicId, ExpiresAt: time.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := users.SoftDelete(user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.HardDelete(user); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		model interface{}
+		query string
+		arg   interface{}
+	}{
+		{"user", &models.User{}, "id = ?", user.ID},
+		{"role assignments", nil, "user_id = ?", user.ID},
+		{"recovery codes", &models.RecoveryCode{}, "user_id = ?", user.ID},
+		{"refresh tokens", &models.RefreshToken{}, "user_id = ?", user.PublicId},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			query := db.Model(tc.model)
+			if tc.model == nil {
+				query = db.Table("user_roles")
+			}
+			var count int64
+			if err := query.Where(tc.query, tc.arg).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			if count != 0 {
+				t.Errorf("%d rows left", count)
+			}
+		})
+	}
+
+	createUser(t, users, "alice")
+}